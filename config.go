@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"github.com/mitchellh/mapstructure"
+	"github.com/mutschler/mt/sheet"
 	log "github.com/sirupsen/logrus"
 	flag "github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -13,8 +14,11 @@ const (
 	configName = "mt"
 	configType = "json"
 
-	blurThreshold  = 62
-	blankThreshold = 85
+	blurThreshold  = sheet.DefaultBlurThreshold
+	blankThreshold = sheet.DefaultBlankThreshold
+
+	creditsBlankThreshold = sheet.DefaultCreditsBlankThreshold
+	creditsBlurThreshold  = sheet.DefaultCreditsBlurThreshold
 )
 
 type config struct {
@@ -22,12 +26,37 @@ type config struct {
 	Numcaps int `json:"numcaps"`
 	// Columns are how many columns should be used in the contact sheet.
 	Columns int `json:"columns"`
+	// Rows are how many rows should be used in the contact sheet, overrides
+	// columns.
+	Rows int `json:"rows"`
+	// SheetAspect is the aspect ratio the contact sheet should come close to,
+	// e.g. "16:9" or "a4", overrides columns.
+	SheetAspect string `json:"sheet_aspect"`
+	// MaxRows splits the contact sheet into pages of at most this many rows.
+	MaxRows int `json:"max_rows"`
+	// MaxPerPage splits the contact sheet into pages of at most this many
+	// thumbnails.
+	MaxPerPage int `json:"max_per_page"`
+	// Order is the placement order of thumbnails: rows, columns or
+	// serpentine.
+	Order string `json:"order"`
+	// Layout is grid or hero.
+	Layout string `json:"layout"`
+	// HeroFrame selects the featured frame of the hero layout: middle,
+	// sharpest or a timestamp.
+	HeroFrame string `json:"hero_frame"`
+	// HeroSpan is the number of cells the featured frame spans.
+	HeroSpan int `json:"hero_span"`
 	// Padding is how much padding (in pixels) to add around thumbnails in the
 	// contact sheet.
 	Padding int `json:"padding"`
 	// Width is the width of a single thumbnail.
 	Width int `json:"width"`
 	// TODO is height missing?
+	// SheetWidth is the width of the whole contact sheet, overrides Width.
+	SheetWidth int `json:"sheet_width"`
+	// SheetHeight is the height of the whole contact sheet, overrides Width.
+	SheetHeight int `json:"sheet_height"`
 	// FontAll is the font to use in the header and timestamps.
 	FontAll string `json:"font_all"`
 	// FontSize is the font size to be used in the contact sheet.
@@ -35,6 +64,22 @@ type config struct {
 	// DisableTimestamps provides the ability to toggle timestamps in the
 	// contact sheet.
 	DisableTimestamps bool `json:"disable_timestamps"`
+	// TimestampFormat sets how timestamps are displayed: hms, ms or smpte.
+	TimestampFormat string `json:"timestamp_format"`
+	// Caption is a template for the text drawn on every screenshot.
+	Caption string `json:"caption"`
+	// TimestampPosition sets the corner or edge of the timestamps.
+	TimestampPosition string `json:"timestamp_position"`
+	// TimestampStyle sets how timestamps are drawn: box, shadow or outline.
+	TimestampStyle string `json:"timestamp_style"`
+	// TimestampMargin sets the distance of timestamps to the image edges.
+	TimestampMargin int `json:"timestamp_margin"`
+	// TimestampFontSize sets the font size of timestamps, 0 uses FontSize.
+	TimestampFontSize int `json:"timestamp_font_size"`
+	// FgTimestamp sets the font color of timestamps (RGB or RGBA).
+	FgTimestamp string `json:"fg_timestamp"`
+	// BgTimestamp sets the box, shadow or outline color of timestamps (RGB or RGBA).
+	BgTimestamp string `json:"bg_timestamp"`
 	// Verbose increases the logging.
 	Verbose bool `json:"verbose"`
 	// SingleImages will create a single image for each screenshot.
@@ -54,18 +99,33 @@ type config struct {
 	Header bool `json:"header"`
 	// HeaderMeta sets whether to include codec, bitrate, and FPS to header.
 	HeaderMeta bool `json:"header_meta"` // Required header to be true?
-	// Filter sets an optional filter on thumbnails. Options are:
-	//   - "greyscale" greyscale color palette?
-	//   - "invert"   invert image?
-	//   - "fancy"     ?
-	//   - "cross"     ?
+	// HeaderLines are templates of the header lines, replacing the default
+	// header.
+	HeaderLines []string `json:"header_lines"`
+	// HeaderFile is a file with one header line template per line.
+	HeaderFile string `json:"header_file"`
+	// HeaderPosition places the header at the top, bottom, left or right.
+	HeaderPosition string `json:"header_position"`
+	// HeaderColumns is the number of header columns, 1 or 2.
+	HeaderColumns int `json:"header_columns"`
+	// HeaderRightLines are templates of the right header column.
+	HeaderRightLines []string `json:"header_right_lines"`
+	// HeaderOverflow sets if long header lines are wrapped or ellipsized.
+	HeaderOverflow string `json:"header_overflow"`
+	// Filter sets optional filters on thumbnails as a comma separated list,
+	// see --filters for the available filters and their parameters.
 	Filter string `json:"filter"`
 	// Filename is the name of the contact sheet.
 	Filename string `json:"filename"`
-	// To is the starting timestamp.
+	// From is the starting timestamp.
 	From string `json:"from"`
 	// To is the ending timestamp.
 	To string `json:"to"`
+	// At lists the timestamps to capture, overriding numcaps, interval,
+	// from and to.
+	At []string `json:"at"`
+	// AtFile is a file with one timestamp to capture per line.
+	AtFile string `json:"at_file"`
 	// SkipExisting skips movie if there is and existing contact sheet.
 	SkipExisting bool `json:"skip_existing"`
 	// Overwrite will enable the ability to overwrite existing contact sheets.
@@ -77,6 +137,8 @@ type config struct {
 	Watermark string `json:"watermark"`
 	// Fast enables faster creation of thumbnails. May result in duplicate screens.
 	Fast bool `json:"fast"`
+	// AutoCrop enables cropping of black borders.
+	AutoCrop bool `json:"autocrop"`
 	// WatermarkAll sets a provided images as the watermark in each thumbnail.
 	WatermarkAll string `json:"watermark_all"`
 	// Comment sets a line of text that will be displayed in the bottom-left corner
@@ -89,6 +151,28 @@ type config struct {
 	BlurThreshold int `json:"blur_threshold"`
 	// BlankThreshold sets the threshold for blank detection in thumbnails.
 	BlankThreshold int `json:"blank_threshold"`
+	// SkipIntro sets the ability to detect and skip an intro at the start.
+	SkipIntro bool `json:"skip_intro"`
+	// CreditsBlankThreshold sets the percentage of dark or white pixels from
+	// which on a frame is considered intro or credits.
+	CreditsBlankThreshold int `json:"credits_blank_threshold"`
+	// CreditsBlurThreshold sets the percentage of pixels without edges from
+	// which on a frame is considered intro or credits.
+	CreditsBlurThreshold int `json:"credits_blur_threshold"`
+	// DedupeThreshold sets the hamming distance up to which thumbnails are
+	// considered near duplicates and skipped, 0 disables it.
+	DedupeThreshold int `json:"dedupe_threshold"`
+	// Candidates sets how many candidate frames are compared per thumbnail.
+	Candidates int `json:"candidates"`
+	// CandidateWindow sets the time span (in seconds) candidates are taken from.
+	CandidateWindow int `json:"candidate_window"`
+	// SkipRetries sets how often a skipped frame is retried.
+	SkipRetries int `json:"skip_retries"`
+	// SkipStep sets the distance (in seconds) between retries.
+	SkipStep int `json:"skip_step"`
+	// SkipDirection sets where retries are searched: forward, backward or
+	// alternate.
+	SkipDirection string `json:"skip_direction"`
 	// WebVTT generates a webtt file when enabled.
 	WebVTT bool `json:"webvtt"`
 	// VTT ??
@@ -97,6 +181,31 @@ type config struct {
 	Upload bool `json:"upload"`
 	// UploadURL sets the upload URL.
 	UploadUrl string `json:"upload_url"`
+	// Jobs sets how many frames are extracted and processed concurrently.
+	Jobs int `json:"jobs"`
+	// ParallelFiles sets how many files are processed concurrently.
+	ParallelFiles int `json:"parallel_files"`
+	// Select sets how capture points are selected ("even" or "scenes").
+	Select string `json:"select"`
+	// SceneSamples sets how many frames per capture are sampled to find scene cuts.
+	SceneSamples int `json:"scene_samples"`
+}
+
+// returns the parsed sheet_aspect setting, 0 if it is empty
+func sheetAspect() (float64, error) {
+	if viper.GetString("sheet_aspect") == "" {
+		return 0, nil
+	}
+	return sheet.ParseAspect(viper.GetString("sheet_aspect"))
+}
+
+// configCompat maps keys renamed since older config files to their current
+// name, values set on the commandline still take precedence.
+func configCompat() {
+	if viper.InConfig("end") && !viper.InConfig("to") {
+		log.Warn("config key \"end\" is deprecated, please use \"to\" instead")
+		viper.SetDefault("to", viper.GetString("end"))
+	}
 }
 
 // configInit sets default variables and reads configuration file.
@@ -113,19 +222,39 @@ func configInit() {
 	// Set mt defaults
 	viper.SetDefault("numcaps", 4)
 	viper.SetDefault("columns", 2)
+	viper.SetDefault("rows", 0)
+	viper.SetDefault("sheet_aspect", "")
+	viper.SetDefault("max_rows", 0)
+	viper.SetDefault("max_per_page", 0)
+	viper.SetDefault("order", sheet.OrderRows)
+	viper.SetDefault("layout", sheet.LayoutGrid)
+	viper.SetDefault("hero_frame", sheet.HeroMiddle)
+	viper.SetDefault("hero_span", 2)
 	viper.SetDefault("padding", 10)
 	viper.SetDefault("width", 400)
 	viper.SetDefault("height", 0)
+	viper.SetDefault("sheet_width", 0)
+	viper.SetDefault("sheet_height", 0)
 	viper.SetDefault("font_all", "DroidSans.ttf") // Should this be Ubuntu.ttf to match readme?
 	viper.SetDefault("font_size", 12)
 	viper.SetDefault("disable_timestamps", false)
 	viper.SetDefault("timestamp_opacity", 1.0)
+	viper.SetDefault("timestamp_position", sheet.PositionBottomRight)
+	viper.SetDefault("timestamp_style", sheet.StyleBox)
+	viper.SetDefault("timestamp_margin", 10)
+	viper.SetDefault("timestamp_font_size", 0)
+	viper.SetDefault("fg_timestamp", "255,255,255")
+	viper.SetDefault("bg_timestamp", "0,0,0")
+	viper.SetDefault("timestamp_format", sheet.TimestampHMS)
+	viper.SetDefault("caption", sheet.DefaultCaption)
 	viper.SetDefault("filename", "{{.Path}}{{.Name}}.jpg")
 	viper.SetDefault("verbose", false)
 	viper.SetDefault("bg_content", "0,0,0")
 	viper.SetDefault("border", 0)
 	viper.SetDefault("from", "00:00:00")
-	viper.SetDefault("end", "00:00:00")
+	viper.SetDefault("at", []string{})
+	viper.SetDefault("at_file", "")
+	viper.SetDefault("to", "00:00:00")
 	viper.SetDefault("single_images", false)
 	viper.SetDefault("header", true)
 	viper.SetDefault("font_dirs", []string{})
@@ -133,6 +262,12 @@ func configInit() {
 	viper.SetDefault("fg_header", "255,255,255")
 	viper.SetDefault("header_image", "")
 	viper.SetDefault("header_meta", false)
+	viper.SetDefault("header_lines", []string{})
+	viper.SetDefault("header_file", "")
+	viper.SetDefault("header_position", sheet.PositionTop)
+	viper.SetDefault("header_columns", 1)
+	viper.SetDefault("header_right_lines", []string{})
+	viper.SetDefault("header_overflow", sheet.HeaderWrap)
 	viper.SetDefault("watermark", "")
 	viper.SetDefault("comment", "contact sheet created with mt (https://github.com/mutschler/mt)")
 	viper.SetDefault("watermark-all", "")
@@ -143,21 +278,36 @@ func configInit() {
 	viper.SetDefault("overwrite", false)
 	viper.SetDefault("sfw", false)
 	viper.SetDefault("fast", false)
+	viper.SetDefault("autocrop", false)
 	viper.SetDefault("show_config", false)
 	viper.SetDefault("webvtt", false)
 	viper.SetDefault("vtt", false)
 	viper.SetDefault("blur_threshold", blurThreshold)
 	viper.SetDefault("blank_threshold", blankThreshold)
+	viper.SetDefault("dedupe_threshold", 0)
+	viper.SetDefault("candidates", 1)
+	viper.SetDefault("candidate_window", 10)
+	viper.SetDefault("skip_retries", 3)
+	viper.SetDefault("skip_step", 10)
+	viper.SetDefault("skip_direction", sheet.SkipForward)
 	viper.SetDefault("upload", false)
 	viper.SetDefault("upload_url", "http://example.com/upload")
 	viper.SetDefault("skip_credits", false)
+	viper.SetDefault("skip_intro", false)
+	viper.SetDefault("credits_blank_threshold", creditsBlankThreshold)
+	viper.SetDefault("credits_blur_threshold", creditsBlurThreshold)
 	viper.SetDefault("interval", 0)
+	viper.SetDefault("jobs", 1)
+	viper.SetDefault("parallel_files", 1)
+	viper.SetDefault("select", sheet.SelectEven)
+	viper.SetDefault("scene_samples", 10)
 
 	err := viper.ReadInConfig()
 	if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 		log.Info("configuration file not found, using defaults")
 	}
 	log.Info("loaded config file")
+	configCompat()
 
 	// Bind values in config file to commandline flags
 	var bindErr error
@@ -169,6 +319,38 @@ func configInit() {
 	bindErr = viper.BindPFlag("columns", flag.Lookup("columns"))
 	flagBindErrorHandling(bindErr)
 
+	flag.Int("rows", viper.GetInt("rows"), "number of rows, overrides columns (defaults to 0, disabled)")
+	bindErr = viper.BindPFlag("rows", flag.Lookup("rows"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("sheet-aspect", viper.GetString("sheet_aspect"), "choose columns and rows for a sheet close to this aspect ratio, e.g. 16:9, 1.5, a4 or a4-landscape, overrides columns")
+	bindErr = viper.BindPFlag("sheet_aspect", flag.Lookup("sheet-aspect"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("max-rows", viper.GetInt("max_rows"), "split the contact sheet into pages of at most this many rows (defaults to 0, disabled)")
+	bindErr = viper.BindPFlag("max_rows", flag.Lookup("max-rows"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("max-per-page", viper.GetInt("max_per_page"), "split the contact sheet into pages of at most this many screenshots (defaults to 0, disabled)")
+	bindErr = viper.BindPFlag("max_per_page", flag.Lookup("max-per-page"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("order", viper.GetString("order"), "placement order of screenshots: rows (left to right), columns (top to bottom) or serpentine (alternating direction per row) (defaults to rows)")
+	bindErr = viper.BindPFlag("order", flag.Lookup("order"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("layout", viper.GetString("layout"), "grid or hero, hero renders one featured frame across several cells (defaults to grid)")
+	bindErr = viper.BindPFlag("layout", flag.Lookup("layout"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("hero-frame", viper.GetString("hero_frame"), "featured frame of the hero layout: middle, sharpest or a timestamp (defaults to middle)")
+	bindErr = viper.BindPFlag("hero_frame", flag.Lookup("hero-frame"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("hero-span", viper.GetInt("hero_span"), "number of cells the featured frame spans in each direction (defaults to 2)")
+	bindErr = viper.BindPFlag("hero_span", flag.Lookup("hero-span"))
+	flagBindErrorHandling(bindErr)
+
 	flag.IntP("padding", "p", viper.GetInt("padding"), "padding between the images in px")
 	bindErr = viper.BindPFlag("padding", flag.Lookup("padding"))
 	flagBindErrorHandling(bindErr)
@@ -177,6 +359,14 @@ func configInit() {
 	bindErr = viper.BindPFlag("width", flag.Lookup("width"))
 	flagBindErrorHandling(bindErr)
 
+	flag.Int("sheet-width", viper.GetInt("sheet_width"), "width of the whole contact sheet in px, overrides width (defaults to 0, disabled)")
+	bindErr = viper.BindPFlag("sheet_width", flag.Lookup("sheet-width"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("sheet-height", viper.GetInt("sheet_height"), "height of the whole contact sheet in px including the header, overrides width (defaults to 0, disabled)")
+	bindErr = viper.BindPFlag("sheet_height", flag.Lookup("sheet-height"))
+	flagBindErrorHandling(bindErr)
+
 	flag.StringP("font", "f", viper.GetString("font_all"), "font to use for timestamps and header information")
 	bindErr = viper.BindPFlag("font_all", flag.Lookup("font"))
 	flagBindErrorHandling(bindErr)
@@ -189,6 +379,38 @@ func configInit() {
 	bindErr = viper.BindPFlag("disable_timestamps", flag.Lookup("disable-timestamps"))
 	flagBindErrorHandling(bindErr)
 
+	flag.String("timestamp-format", viper.GetString("timestamp_format"), "display timestamps as hms (HH:MM:SS), ms (HH:MM:SS.mmm) or smpte (HH:MM:SS:FF) (defaults to hms)")
+	bindErr = viper.BindPFlag("timestamp_format", flag.Lookup("timestamp-format"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("caption", viper.GetString("caption"), "template for the text on every screenshot, fields: {{.Timestamp}} {{.Index}} {{.Total}} {{.FrameNumber}} {{.Percent}} {{.Remaining}}")
+	bindErr = viper.BindPFlag("caption", flag.Lookup("caption"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("timestamp-position", viper.GetString("timestamp_position"), "position of the timestamps: top-left, top, top-right, left, center, right, bottom-left, bottom or bottom-right")
+	bindErr = viper.BindPFlag("timestamp_position", flag.Lookup("timestamp-position"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("timestamp-style", viper.GetString("timestamp_style"), "draw timestamps on a box, with a shadow or with an outline (box, shadow or outline)")
+	bindErr = viper.BindPFlag("timestamp_style", flag.Lookup("timestamp-style"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("timestamp-margin", viper.GetInt("timestamp_margin"), "distance of the timestamps to the image edges in pixels")
+	bindErr = viper.BindPFlag("timestamp_margin", flag.Lookup("timestamp-margin"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("timestamp-font-size", viper.GetInt("timestamp_font_size"), "font size of the timestamps (defaults to font-size)")
+	bindErr = viper.BindPFlag("timestamp_font_size", flag.Lookup("timestamp-font-size"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("fg-timestamp", viper.GetString("fg_timestamp"), "rgb or rgba font color for timestamps")
+	bindErr = viper.BindPFlag("fg_timestamp", flag.Lookup("fg-timestamp"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("bg-timestamp", viper.GetString("bg_timestamp"), "rgb or rgba box, shadow or outline color for timestamps, e.g. 0,0,0,128")
+	bindErr = viper.BindPFlag("bg_timestamp", flag.Lookup("bg-timestamp"))
+	flagBindErrorHandling(bindErr)
+
 	flag.BoolP("verbose", "v", viper.GetBool("verbose"), "enable verbose output")
 	bindErr = viper.BindPFlag("verbose", flag.Lookup("verbose"))
 	flagBindErrorHandling(bindErr)
@@ -225,11 +447,11 @@ func configInit() {
 	bindErr = viper.BindPFlag("watermark_all", flag.Lookup("watermark-all"))
 	flagBindErrorHandling(bindErr)
 
-	flag.BoolP("skip-blank", "b", viper.GetBool("skip_blank"), "skip up to 3 images in a row which seem to be blank (can slow mt down)")
+	flag.BoolP("skip-blank", "b", viper.GetBool("skip_blank"), "retry up to skip-retries times if an image seems to be blank (can slow mt down)")
 	bindErr = viper.BindPFlag("skip_blank", flag.Lookup("skip-blank"))
 	flagBindErrorHandling(bindErr)
 
-	flag.Bool("skip-blurry", viper.GetBool("skip_blurry"), "skip up to 3 images in a row which seem to be blurry (can slow mt down)")
+	flag.Bool("skip-blurry", viper.GetBool("skip_blurry"), "retry up to skip-retries times if an image seems to be blurry (can slow mt down)")
 	bindErr = viper.BindPFlag("skip_blurry", flag.Lookup("skip-blurry"))
 	flagBindErrorHandling(bindErr)
 
@@ -245,6 +467,22 @@ func configInit() {
 	bindErr = viper.BindPFlag("header_meta", flag.Lookup("header-meta"))
 	flagBindErrorHandling(bindErr)
 
+	flag.String("header-file", viper.GetString("header_file"), "use the header line templates listed in this file, one per line, e.g. File: {{.Name}} ({{.Size}})")
+	bindErr = viper.BindPFlag("header_file", flag.Lookup("header-file"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("header-position", viper.GetString("header_position"), "place the header at the top, the bottom (footer) or as a sidebar on the left or right")
+	bindErr = viper.BindPFlag("header_position", flag.Lookup("header-position"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("header-columns", viper.GetInt("header_columns"), "number of header columns, 2 puts the technical metadata on the right")
+	bindErr = viper.BindPFlag("header_columns", flag.Lookup("header-columns"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("header-overflow", viper.GetString("header_overflow"), "wrap or ellipsize header lines which are too long (wrap or ellipsis)")
+	bindErr = viper.BindPFlag("header_overflow", flag.Lookup("header-overflow"))
+	flagBindErrorHandling(bindErr)
+
 	flag.String("filter", viper.GetString("filter"), "apply one or mor filters to images (comma seperated list), see --filters for available filters")
 	bindErr = viper.BindPFlag("filter", flag.Lookup("filter"))
 	flagBindErrorHandling(bindErr)
@@ -257,12 +495,20 @@ func configInit() {
 	bindErr = viper.BindPFlag("filename", flag.Lookup("output"))
 	flagBindErrorHandling(bindErr)
 
-	flag.String("from", viper.GetString("from"), "set starting point in format HH:MM:SS")
+	flag.String("from", viper.GetString("from"), "set starting point in format HH:MM:SS, MM:SS, 1h2m3s or seconds, as percentage (5%) or relative to the end (-00:03:00)")
 	bindErr = viper.BindPFlag("from", flag.Lookup("from"))
 	flagBindErrorHandling(bindErr)
 
-	flag.String("to", viper.GetString("end"), "set end point in format HH:MM:SS")
-	bindErr = viper.BindPFlag("end", flag.Lookup("to"))
+	flag.String("to", viper.GetString("to"), "set end point in format HH:MM:SS, MM:SS, 1h2m3s or seconds, as percentage (95%) or relative to the end (-00:03:00)")
+	bindErr = viper.BindPFlag("to", flag.Lookup("to"))
+	flagBindErrorHandling(bindErr)
+
+	flag.StringSlice("at", viper.GetStringSlice("at"), "capture exactly these comma separated timestamps in format HH:MM:SS[.ms], MM:SS, 1h2m3s or seconds, overrides numcaps, interval, from and to")
+	bindErr = viper.BindPFlag("at", flag.Lookup("at"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("at-file", viper.GetString("at_file"), "capture the timestamps listed in this file, one per line")
+	bindErr = viper.BindPFlag("at_file", flag.Lookup("at-file"))
 	flagBindErrorHandling(bindErr)
 
 	flag.String("save-config", viper.GetString("save_config"), "save config with current settings to this file")
@@ -293,6 +539,10 @@ func configInit() {
 	bindErr = viper.BindPFlag("fast", flag.Lookup("fast"))
 	flagBindErrorHandling(bindErr)
 
+	flag.Bool("autocrop", viper.GetBool("autocrop"), "detect black borders and crop them off every screenshot (defaults to false)")
+	bindErr = viper.BindPFlag("autocrop", flag.Lookup("autocrop"))
+	flagBindErrorHandling(bindErr)
+
 	flag.Bool("webvtt", viper.GetBool("webvtt"), "create a .vtt file: disables header, header-meta, padding and timestamps")
 	bindErr = viper.BindPFlag("webvtt", flag.Lookup("webvtt"))
 	flagBindErrorHandling(bindErr)
@@ -309,6 +559,30 @@ func configInit() {
 	bindErr = viper.BindPFlag("blank_threshold", flag.Lookup("blank-threshold"))
 	flagBindErrorHandling(bindErr)
 
+	flag.Int("dedupe-threshold", viper.GetInt("dedupe_threshold"), "retry up to skip-retries times if the perceptual hash of an image is within this distance (0-64) of an earlier thumbnail (defaults to 0, disabled)")
+	bindErr = viper.BindPFlag("dedupe_threshold", flag.Lookup("dedupe-threshold"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("candidates", viper.GetInt("candidates"), "capture this many candidate frames per thumbnail and keep the sharpest, best exposed one (defaults to 1, disabled)")
+	bindErr = viper.BindPFlag("candidates", flag.Lookup("candidates"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("candidate-window", viper.GetInt("candidate_window"), "time span in seconds around every capture point to take candidates from (defaults to 10)")
+	bindErr = viper.BindPFlag("candidate_window", flag.Lookup("candidate-window"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("skip-retries", viper.GetInt("skip_retries"), "number of retries for a skipped frame (defaults to 3)")
+	bindErr = viper.BindPFlag("skip_retries", flag.Lookup("skip-retries"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("skip-step", viper.GetInt("skip_step"), "seconds between retries of a skipped frame (defaults to 10)")
+	bindErr = viper.BindPFlag("skip_step", flag.Lookup("skip-step"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("skip-direction", viper.GetString("skip_direction"), "search retries forward, backward or alternate around the capture point (defaults to forward)")
+	bindErr = viper.BindPFlag("skip_direction", flag.Lookup("skip-direction"))
+	flagBindErrorHandling(bindErr)
+
 	flag.Bool("upload", viper.GetBool("upload"), "post file via http form submit")
 	bindErr = viper.BindPFlag("upload", flag.Lookup("upload"))
 	flagBindErrorHandling(bindErr)
@@ -321,13 +595,115 @@ func configInit() {
 	bindErr = viper.BindPFlag("interval", flag.Lookup("interval"))
 	flagBindErrorHandling(bindErr)
 
-	flag.Bool("skip-credits", viper.GetBool("skip_credits"), "tries to skip ending credits from screencap creation by cutting off 4 minutes or 10 percent of the clip (defaults to false)")
+	flag.Bool("skip-credits", viper.GetBool("skip_credits"), "detect ending credits and end screencap creation before them (defaults to false)")
 	bindErr = viper.BindPFlag("skip_credits", flag.Lookup("skip-credits"))
 	flagBindErrorHandling(bindErr)
 
+	flag.Bool("skip-intro", viper.GetBool("skip_intro"), "detect an intro and start screencap creation after it (defaults to false)")
+	bindErr = viper.BindPFlag("skip_intro", flag.Lookup("skip-intro"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("credits-blank-threshold", viper.GetInt("credits_blank_threshold"), "percentage of dark or white pixels from which on a frame is considered intro or credits (defaults to 80)")
+	bindErr = viper.BindPFlag("credits_blank_threshold", flag.Lookup("credits-blank-threshold"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("credits-blur-threshold", viper.GetInt("credits_blur_threshold"), "percentage of pixels without edges from which on a frame is considered intro or credits (defaults to 90)")
+	bindErr = viper.BindPFlag("credits_blur_threshold", flag.Lookup("credits-blur-threshold"))
+	flagBindErrorHandling(bindErr)
+
+	flag.IntP("jobs", "j", viper.GetInt("jobs"), "number of frames to extract and process concurrently, each job opens the file once (defaults to 1)")
+	bindErr = viper.BindPFlag("jobs", flag.Lookup("jobs"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("parallel-files", viper.GetInt("parallel_files"), "number of files to process concurrently (defaults to 1)")
+	bindErr = viper.BindPFlag("parallel_files", flag.Lookup("parallel-files"))
+	flagBindErrorHandling(bindErr)
+
+	flag.String("select", viper.GetString("select"), "how to select capture points: even (evenly spaced) or scenes (strongest scene cuts)")
+	bindErr = viper.BindPFlag("select", flag.Lookup("select"))
+	flagBindErrorHandling(bindErr)
+
+	flag.Int("scene-samples", viper.GetInt("scene_samples"), "frames sampled per capture to find scene cuts with --select=scenes (defaults to 10)")
+	bindErr = viper.BindPFlag("scene_samples", flag.Lookup("scene-samples"))
+	flagBindErrorHandling(bindErr)
+
 	flag.Parse()
 }
 
+// optionsFromConfig converts the current settings into sheet.Options.
+func optionsFromConfig() sheet.Options {
+	// sheet_aspect is validated on startup
+	aspect, _ := sheetAspect()
+
+	return sheet.Options{
+		Numcaps:               viper.GetInt("numcaps"),
+		Columns:               viper.GetInt("columns"),
+		Rows:                  viper.GetInt("rows"),
+		SheetAspect:           aspect,
+		MaxRows:               viper.GetInt("max_rows"),
+		MaxPerPage:            viper.GetInt("max_per_page"),
+		Order:                 viper.GetString("order"),
+		Layout:                viper.GetString("layout"),
+		HeroFrame:             viper.GetString("hero_frame"),
+		HeroSpan:              viper.GetInt("hero_span"),
+		Padding:               viper.GetInt("padding"),
+		Width:                 viper.GetInt("width"),
+		Height:                viper.GetInt("height"),
+		SheetWidth:            viper.GetInt("sheet_width"),
+		SheetHeight:           viper.GetInt("sheet_height"),
+		Font:                  viper.GetString("font_all"),
+		FontSize:              viper.GetInt("font_size"),
+		DisableTimestamps:     viper.GetBool("disable_timestamps"),
+		TimestampOpacity:      viper.GetFloat64("timestamp_opacity"),
+		TimestampPosition:     viper.GetString("timestamp_position"),
+		TimestampStyle:        viper.GetString("timestamp_style"),
+		TimestampMargin:       viper.GetInt("timestamp_margin"),
+		TimestampFontSize:     viper.GetInt("timestamp_font_size"),
+		FgTimestamp:           getImageColor(viper.GetString("fg_timestamp"), []int{255, 255, 255}),
+		BgTimestamp:           getImageColor(viper.GetString("bg_timestamp"), []int{0, 0, 0}),
+		TimestampFormat:       viper.GetString("timestamp_format"),
+		Caption:               viper.GetString("caption"),
+		SingleImages:          viper.GetBool("single_images"),
+		BgHeader:              getImageColor(viper.GetString("bg_header"), []int{0, 0, 0}),
+		FgHeader:              getImageColor(viper.GetString("fg_header"), []int{255, 255, 255}),
+		BgContent:             getImageColor(viper.GetString("bg_content"), []int{0, 0, 0}),
+		HeaderImage:           viper.GetString("header_image"),
+		Header:                viper.GetBool("header"),
+		HeaderMeta:            viper.GetBool("header_meta"),
+		HeaderPosition:        viper.GetString("header_position"),
+		HeaderColumns:         viper.GetInt("header_columns"),
+		HeaderRightLines:      viper.GetStringSlice("header_right_lines"),
+		HeaderOverflow:        viper.GetString("header_overflow"),
+		Comment:               viper.GetString("comment"),
+		Watermark:             viper.GetString("watermark"),
+		WatermarkAll:          viper.GetString("watermark_all"),
+		Filter:                viper.GetString("filter"),
+		From:                  viper.GetString("from"),
+		To:                    viper.GetString("to"),
+		Interval:              viper.GetInt("interval"),
+		SkipCredits:           viper.GetBool("skip_credits"),
+		SkipIntro:             viper.GetBool("skip_intro"),
+		SkipBlank:             viper.GetBool("skip_blank"),
+		SkipBlurry:            viper.GetBool("skip_blurry"),
+		SFW:                   viper.GetBool("sfw"),
+		BlurThreshold:         viper.GetInt("blur_threshold"),
+		BlankThreshold:        viper.GetInt("blank_threshold"),
+		CreditsBlankThreshold: viper.GetInt("credits_blank_threshold"),
+		CreditsBlurThreshold:  viper.GetInt("credits_blur_threshold"),
+		DedupeThreshold:       viper.GetInt("dedupe_threshold"),
+		Candidates:            viper.GetInt("candidates"),
+		CandidateWindow:       viper.GetInt("candidate_window"),
+		SkipRetries:           viper.GetInt("skip_retries"),
+		SkipStep:              viper.GetInt("skip_step"),
+		SkipDirection:         viper.GetString("skip_direction"),
+		Fast:                  viper.GetBool("fast"),
+		AutoCrop:              viper.GetBool("autocrop"),
+		Select:                viper.GetString("select"),
+		SceneSamples:          viper.GetInt("scene_samples"),
+		Jobs:                  viper.GetInt("jobs"),
+	}
+}
+
 func saveConfig(configurationPath string) error {
 	var currentConfig config
 	err := mapstructure.WeakDecode(viper.AllSettings(), &currentConfig)
@@ -0,0 +1,107 @@
+package filter
+
+import (
+	"bytes"
+	"image"
+	"math/rand"
+	"time"
+
+	"github.com/disintegration/gift"
+	"github.com/disintegration/imaging"
+	"github.com/mutschler/mt/internal/bindata"
+)
+
+func init() {
+	Register(&Filter{
+		Name:        "invert",
+		Description: "invert colors",
+		Apply: func(img image.Image, args Args) image.Image {
+			return imaging.Invert(img)
+		},
+	})
+
+	Register(&Filter{
+		Name:        "greyscale",
+		Description: "convert to greyscale image",
+		Params: []Param{
+			{Name: "sharpen", Default: 1.0, Description: "sharpening sigma"},
+			{Name: "contrast", Default: 20, Description: "contrast change in percent"},
+		},
+		Apply: func(img image.Image, args Args) image.Image {
+			img = imaging.Grayscale(img)
+			img = imaging.Sharpen(img, args.Params["sharpen"])
+			return imaging.AdjustContrast(img, args.Params["contrast"])
+		},
+	})
+
+	Register(&Filter{
+		Name:        "sepia",
+		Description: "convert to sepia image",
+		Params: []Param{
+			{Name: "strength", Default: 100, Description: "sepia strength in percent"},
+		},
+		Apply: func(img image.Image, args Args) image.Image {
+			g := gift.New(
+				gift.Sepia(float32(args.Params["strength"])),
+			)
+			dst := image.NewRGBA(g.Bounds(img.Bounds()))
+			g.Draw(dst, img)
+			return dst
+		},
+	})
+
+	Register(&Filter{
+		Name:        "fancy",
+		Description: "randomly rotates every image",
+		Params: []Param{
+			{Name: "min", Default: -10, Description: "minimum rotation in degrees"},
+			{Name: "max", Default: 15, Description: "maximum rotation in degrees"},
+		},
+		StampFirst: true,
+		Apply: func(img image.Image, args Args) image.Image {
+			//TODO: find a way to do this without GIFT...
+			g := gift.New(
+				gift.Rotate(randomAngle(args.Params["min"], args.Params["max"]), args.Background, gift.CubicInterpolation),
+			)
+			dst := image.NewRGBA(g.Bounds(img.Bounds()))
+			g.Draw(dst, img)
+			return dst
+		},
+	})
+
+	Register(&Filter{
+		Name:        "cross",
+		Description: "simulated cross processing",
+		Params: []Param{
+			{Name: "midpoint", Default: Midpoint, Description: "sigmoid midpoint from 0 to 1"},
+			{Name: "factor", Default: Factor, Description: "sigmoid contrast factor"},
+		},
+		Apply: func(img image.Image, args Args) image.Image {
+			return CrossProcessingWith(img, args.Params["midpoint"], args.Params["factor"])
+		},
+	})
+
+	Register(&Filter{
+		Name:        "strip",
+		Description: "simulate an old 35mm Film strip",
+		StampFirst:  true,
+		Apply: func(img image.Image, args Args) image.Image {
+			l, _ := bindata.Asset("strip_left.jpg")
+			strip, _ := imaging.Decode(bytes.NewReader(l))
+
+			r, _ := bindata.Asset("strip_right.jpg")
+			stripr, _ := imaging.Decode(bytes.NewReader(r))
+
+			return AddStripsToImage(img, strip, stripr)
+		},
+	})
+}
+
+// returns a random angle in degrees between min (inclusive) and max (exclusive)
+func randomAngle(min, max float64) float32 {
+	if max <= min {
+		return float32(min)
+	}
+	rnd := rand.New(rand.NewSource(time.Now().UTC().UnixNano()))
+	return float32(min + rnd.Float64()*(max-min))
+}
@@ -0,0 +1,13 @@
+package filter
+
+import "errors"
+
+var (
+	// ErrUnknownFilter occurs when a filter name is not registered.
+	ErrUnknownFilter = errors.New("unknown filter")
+	// ErrUnknownParam occurs when a filter has no parameter with the given name
+	// or too many positional parameters are given.
+	ErrUnknownParam = errors.New("unknown filter parameter")
+	// ErrInvalidParam occurs when a parameter value is not a number.
+	ErrInvalidParam = errors.New("invalid filter parameter")
+)
@@ -33,12 +33,17 @@ func AddStripsToImage(img, leftStrip, rightStrip image.Image) image.Image {
 // CrossProcessing wraps the sigmoid function to simulate image cross processing.
 // Best results with midpoint: 0.5 and factor 10
 func CrossProcessing(img image.Image) *image.NRGBA {
+	return CrossProcessingWith(img, Midpoint, Factor)
+}
+
+// CrossProcessingWith is CrossProcessing with a custom midpoint and factor.
+func CrossProcessingWith(img image.Image, midpoint, factor float64) *image.NRGBA {
 	// TODO:  move these to a colours package?
 	red := make([]uint8, 256)
 	green := make([]uint8, 256)
 	blue := make([]uint8, 256)
-	a := math.Min(math.Max(Midpoint, 0.0), 1.0)
-	b := math.Abs(Factor)
+	a := math.Min(math.Max(midpoint, 0.0), 1.0)
+	b := math.Abs(factor)
 	sig0 := sigmoid(a, b, 0)
 	sig1 := sigmoid(a, b, 1)
 
@@ -0,0 +1,133 @@
+package filter
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Param describes a tunable parameter of a Filter.
+type Param struct {
+	// Name is used to set the parameter, e.g. "midpoint" in "cross:midpoint=0.4".
+	Name string
+	// Default is used if the parameter is not set.
+	Default float64
+	// Description is shown in the list of available filters.
+	Description string
+}
+
+// Args are passed to Filter.Apply.
+type Args struct {
+	// Params holds a value for every Param of the filter.
+	Params map[string]float64
+	// Background is the background color of the contact sheet, used to fill
+	// areas uncovered by the filter.
+	Background color.Color
+}
+
+// Filter is a named image filter which can be registered with Register.
+type Filter struct {
+	// Name is used to select the filter, e.g. "sepia".
+	Name string
+	// Description is shown in the list of available filters.
+	Description string
+	// Params are the parameters of the filter in positional order.
+	Params []Param
+	// StampFirst marks filters which need the timestamp to be drawn on the
+	// image before the filter is applied, no timestamp is drawn afterwards.
+	StampFirst bool
+	// Apply returns the filtered image.
+	Apply func(img image.Image, args Args) image.Image
+}
+
+// Instance is a Filter together with the parameter values to apply it with.
+type Instance struct {
+	*Filter
+	Params map[string]float64
+}
+
+// Apply applies the filter instance to img.
+func (i Instance) Apply(img image.Image, background color.Color) image.Image {
+	return i.Filter.Apply(img, Args{Params: i.Params, Background: background})
+}
+
+var (
+	registry = map[string]*Filter{}
+	names    []string
+)
+
+// Register makes a filter available by its name. It panics if a filter with
+// the same name is already registered.
+func Register(f *Filter) {
+	if _, ok := registry[f.Name]; ok {
+		panic(fmt.Sprintf("filter: Register called twice for %s", f.Name))
+	}
+	registry[f.Name] = f
+	names = append(names, f.Name)
+}
+
+// Lookup returns the filter registered as name.
+func Lookup(name string) (*Filter, bool) {
+	f, ok := registry[name]
+	return f, ok
+}
+
+// All returns all registered filters in the order they were registered.
+func All() []*Filter {
+	filters := make([]*Filter, 0, len(names))
+	for _, name := range names {
+		filters = append(filters, registry[name])
+	}
+	return filters
+}
+
+// Parse parses a comma separated list of filters. Parameters follow the
+// filter name separated by colons, either by name or by position:
+//
+//	sepia:60,cross:midpoint=0.4:factor=8,fancy:min=-5:max=5
+//
+// An empty spec or "none" returns no filters.
+func Parse(spec string) ([]Instance, error) {
+	var instances []Instance
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" || s == "none" {
+			continue
+		}
+
+		fields := strings.Split(s, ":")
+		f, ok := Lookup(fields[0])
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownFilter, fields[0])
+		}
+
+		params := map[string]float64{}
+		for _, p := range f.Params {
+			params[p.Name] = p.Default
+		}
+
+		for pos, field := range fields[1:] {
+			name, value := "", field
+			if i := strings.Index(field, "="); i >= 0 {
+				name, value = field[:i], field[i+1:]
+			} else if pos < len(f.Params) {
+				name = f.Params[pos].Name
+			}
+
+			if _, ok := params[name]; !ok {
+				return nil, fmt.Errorf("%w: %q for filter %s", ErrUnknownParam, field, f.Name)
+			}
+
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q for filter %s", ErrInvalidParam, field, f.Name)
+			}
+			params[name] = v
+		}
+
+		instances = append(instances, Instance{Filter: f, Params: params})
+	}
+	return instances, nil
+}
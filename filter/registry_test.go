@@ -0,0 +1,64 @@
+package filter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	instances, err := Parse("sepia:60,cross:midpoint=0.4:factor=8,fancy:min=-5")
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	if len(instances) != 3 {
+		t.Fatalf("got %d filters, wanted 3", len(instances))
+	}
+
+	parseTests := []struct {
+		filter, param string
+		want          float64
+	}{
+		{"sepia", "strength", 60},
+		{"cross", "midpoint", 0.4},
+		{"cross", "factor", 8},
+		{"fancy", "min", -5},
+		{"fancy", "max", 15},
+	}
+
+	for _, tt := range parseTests {
+		for _, i := range instances {
+			if i.Name == tt.filter && i.Params[tt.param] != tt.want {
+				t.Errorf("%s %s got %v want %v", tt.filter, tt.param, i.Params[tt.param], tt.want)
+			}
+		}
+	}
+}
+
+func TestParseNone(t *testing.T) {
+	for _, spec := range []string{"", "none"} {
+		instances, err := Parse(spec)
+		if err != nil || len(instances) != 0 {
+			t.Errorf("Parse(%q) got %v, %v wanted no filters", spec, instances, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	parseErrorTests := []struct {
+		spec string
+		want error
+	}{
+		{"sepia,blur", ErrUnknownFilter},
+		{"sepia:strength=60:amount=4", ErrUnknownParam},
+		{"sepia:60:70", ErrUnknownParam},
+		{"cross:midpoint=half", ErrInvalidParam},
+	}
+
+	for _, tt := range parseErrorTests {
+		_, err := Parse(tt.spec)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q) got %v want %v", tt.spec, err, tt.want)
+		}
+	}
+}
@@ -3,19 +3,15 @@ package main
 import (
 	"bytes"
 	"fmt"
-	"image"
 	"image/color"
-	"math/rand"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
-	"time"
 
-	"github.com/disintegration/gift"
-	"github.com/disintegration/imaging"
-	"github.com/koyachi/go-nude"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -26,12 +22,6 @@ func createTargetDirs(fn string) {
 	os.MkdirAll(path, 0777)
 }
 
-// returns a random float32
-func randomInt(min, max int) float32 {
-	rand.Seed(time.Now().UTC().UnixNano())
-	return float32(rand.Intn(max-min) + min)
-}
-
 // check if given fname exists already
 func fileExists(fname string) bool {
 	if _, err := os.Stat(fname); err == nil {
@@ -40,152 +30,25 @@ func fileExists(fname string) bool {
 	return false
 }
 
-// decides if an image should be skipped based on settings
-func skipImage(img image.Image) bool {
-
-	if viper.GetBool("skip_blurry") {
-		if isBluryImage(img) {
-			return true
-		}
-	}
-
-	if viper.GetBool("skip_blank") {
-		if isBlankImage(img) {
-			return true
-		}
-	}
-
-	if viper.GetBool("sfw") {
-		if isNudeImage(img) {
-			return true
-		}
-	}
-
-	return false
-
-}
-
-// decides if an image is to blury
-func isBluryImage(img image.Image) bool {
-	blur := 0
-	g := gift.New(
-		gift.Convolution(
-			[]float32{
-				-1, -1, -1,
-				-1, 8, -1,
-				-1, -1, -1,
-			},
-			false, false, false, 0.0),
-	)
-	img = imaging.Grayscale(img)
-	dst := image.NewRGBA(g.Bounds(img.Bounds()))
-	g.Draw(dst, img)
-	pixels := 0
-	for x := 0; x < dst.Bounds().Dx(); x++ {
-		for y := 0; y < dst.Bounds().Dy(); y++ {
-			_, _, b, _ := dst.At(x, y).RGBA()
-
-			pixels = pixels + 1
-			// only count blue channel < 4
-			if int(b) < 2056 {
-				blur = blur + 1
-			}
-		}
-	}
-
-	blurPercent := int((float32(blur) / float32(pixels)) * 100)
-	if blurPercent >= viper.GetInt("blur_threshold") {
-		log.Debugf("image is considered blurry (%d), dropping frame", blurPercent)
-		return true
-	}
-	return false
-}
-
-// decides if an image should be considered blank (dark/white)
-func isBlankImage(img image.Image) bool {
-	blankPixels = 0
-	allPixels = 0
-	img = imaging.AdjustFunc(img, countBlankPixels)
-	blankPercent := blankPixels / (allPixels / 100)
-	if blankPercent >= viper.GetInt("blank_threshold") {
-		log.Debugf("image is %d percent black, dropping frame", blankPercent)
-		return true
-	}
-
-	return false
-}
-
-// count pixels which are white and/or black and writes them in blankPixels
-func countBlankPixels(c color.NRGBA) color.NRGBA {
-	//use 55?
-	if int(c.R) < 50 && int(c.G) < 50 && int(c.B) < 50 {
-		blankPixels = blankPixels + 1
-	} else if int(c.R) > 200 && int(c.G) > 200 && int(c.B) > 200 {
-		blankPixels = blankPixels + 1
-	}
-
-	allPixels = allPixels + 1
-
-	return color.NRGBA{c.R, c.G, c.B, c.A}
-}
-
-// takes a time based string 00:00:00 and converts it to milliseconds
-func stringToMS(s string) int64 {
-
-	if s == "0" {
-		return 0
-	}
-
-	x := strings.Split(s, ":")
-
-	if len(x) != 3 {
-		log.Warnf("unable to convert string '%s' into milliseconds string not in format hh:mm:ss", s)
-		return 0
-	}
-
-	hh, _ := strconv.Atoi(x[0])
-	mm, _ := strconv.Atoi(x[1])
-	ss, _ := strconv.Atoi(x[2])
-	ms := 0
-
-	if strings.Contains(x[2], ".") {
-		x = strings.Split(x[2], ".")
-		ss, _ = strconv.Atoi(x[0])
-		ms, _ = strconv.Atoi(x[1])
-	}
-
-	end := ((ss + (mm * 60) + (hh * 60 * 60)) * 1000) + ms
-	return int64(end)
-}
-
-// wrapper for nudity detection
-func isNudeImage(img image.Image) bool {
-	isNude, err := nude.IsImageNude(img)
-	if err != nil {
-		log.Error(err)
-		return false
-	}
-	if isNude {
-		log.Debugf("image skipped because of nudity detection")
-	}
-
-	return isNude
-}
-
-// takes a string "0,0,0" and returns the RGBA color
+// takes a string "0,0,0" or with alpha "0,0,0,128" and returns the RGBA color
 func getImageColor(s string, fallback []int) color.RGBA {
 	colors := strings.Split(s, ",")
 	var r, g, b int
-	if len(colors) == 3 {
+	a := 255
+	if len(colors) == 3 || len(colors) == 4 {
 		r, _ = strconv.Atoi(strings.TrimSpace(colors[0]))
 		g, _ = strconv.Atoi(strings.TrimSpace(colors[1]))
 		b, _ = strconv.Atoi(strings.TrimSpace(colors[2]))
-		log.Debugf("color %s converted to [%d %d %d]", s, r, g, b)
+		if len(colors) == 4 {
+			a, _ = strconv.Atoi(strings.TrimSpace(colors[3]))
+		}
+		log.Debugf("color %s converted to [%d %d %d %d]", s, r, g, b, a)
 	} else {
 		log.Warnf("error converting %s to a valid color, using fallback color: %v", s, fallback)
 		r, g, b = fallback[0], fallback[1], fallback[2]
 	}
-	return color.RGBA{uint8(r), uint8(g), uint8(b), 255}
+	// color.RGBA is alpha-premultiplied
+	return color.RGBAModel.Convert(color.NRGBA{uint8(r), uint8(g), uint8(b), uint8(a)}).(color.RGBA)
 }
 
 // used to construct a save path based on given file info
@@ -237,8 +100,47 @@ func constructSavePath(filename string, c int) string {
 	return buf.String()
 }
 
+// returns the path of a contact sheet saved for movie by an earlier run or
+// an empty string, a sheet split into pages is found by its first page
+func existingSheet(movie string) string {
+	fns := []string{constructSavePath(movie, 0)}
+	if viper.GetInt("max_rows") > 0 || viper.GetInt("max_per_page") > 0 {
+		fns = append(fns, constructSavePath(movie, 1))
+	}
+	for _, fn := range fns {
+		if fileExists(fn) {
+			return fn
+		}
+	}
+	return ""
+}
+
+// reservedPaths holds all save paths handed out by reserveSavePath
+var (
+	reservedPathsMu sync.Mutex
+	reservedPaths   = map[string]bool{}
+)
+
 // gets a filename (string) and returns the absolute path to save the image to...
 func getSavePath(filename string, c int) string {
+	reservedPathsMu.Lock()
+	defer reservedPathsMu.Unlock()
+	return findSavePath(filename, c)
+}
+
+// same as getSavePath but also reserves the returned path so concurrent
+// workers never save to the same file
+func reserveSavePath(filename string, c int) string {
+	reservedPathsMu.Lock()
+	defer reservedPathsMu.Unlock()
+	fname := findSavePath(filename, c)
+	reservedPaths[fname] = true
+	return fname
+}
+
+// returns the first save path which is not existing (unless overwrite is set)
+// and not reserved, reservedPathsMu must be held
+func findSavePath(filename string, c int) string {
 	fname := constructSavePath(filename, c)
 
 	if viper.GetBool("skip_existing") && fileExists(fname) {
@@ -246,10 +148,56 @@ func getSavePath(filename string, c int) string {
 	}
 
 	counter := c
-	for fileExists(fname) && !viper.GetBool("overwrite") {
+	for (fileExists(fname) && !viper.GetBool("overwrite")) || reservedPaths[fname] {
 		//log.Debugf("image already existing at: %s and overwrite is disabled", fname)
 		counter++
 		fname = constructSavePath(filename, counter)
 	}
 	return fname
 }
+
+// returns the timestamps set by --at followed by the ones listed in --at-file,
+// empty lines and lines starting with # are ignored
+func atTimestamps() ([]string, error) {
+	at := viper.GetStringSlice("at")
+	fn := viper.GetString("at_file")
+	if fn == "" {
+		return at, nil
+	}
+
+	b, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		at = append(at, line)
+	}
+	return at, nil
+}
+
+// returns the header line templates of header_lines or, if set, the ones
+// listed in header_file, empty lines are ignored
+func headerLines() ([]string, error) {
+	fn := viper.GetString("header_file")
+	if fn == "" {
+		return viper.GetStringSlice("header_lines"), nil
+	}
+
+	b, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestReserveSavePath(t *testing.T) {
+	viper.Set("filename", "{{.Path}}{{.Name}}.jpg")
+	movie := filepath.Join(t.TempDir(), "movie.mkv")
+
+	first := reserveSavePath(movie, 0)
+	second := reserveSavePath(movie, 0)
+	if first == second {
+		t.Errorf("got %q twice, wanted different save paths", first)
+	}
+}
+
+func TestExistingSheet(t *testing.T) {
+	viper.Set("filename", "{{.Path}}{{.Name}}.jpg")
+	viper.Set("max_rows", 2)
+	defer viper.Set("max_rows", 0)
+	movie := filepath.Join(t.TempDir(), "movie.mkv")
+
+	if got := existingSheet(movie); got != "" {
+		t.Errorf("got %q, wanted no existing sheet", got)
+	}
+
+	page := constructSavePath(movie, 1)
+	if err := ioutil.WriteFile(page, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := existingSheet(movie); got != page {
+		t.Errorf("got %q want %q", got, page)
+	}
+}
+
+func TestAtTimestamps(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "times.txt")
+	if err := ioutil.WriteFile(fn, []byte("# intro\n00:10:00\n\n 00:12:05.500 \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("at", []string{"00:01:30"})
+	viper.Set("at_file", fn)
+	defer viper.Set("at", []string{})
+	defer viper.Set("at_file", "")
+
+	got, err := atTimestamps()
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+	want := []string{"00:01:30", "00:10:00", "00:12:05.500"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestGetImageColor(t *testing.T) {
+	tests := []struct {
+		input string
+		want  color.RGBA
+	}{
+		{"255,255,255", color.RGBA{255, 255, 255, 255}},
+		{"10, 20, 30", color.RGBA{10, 20, 30, 255}},
+		{"255,255,255,0", color.RGBA{0, 0, 0, 0}},
+		{"0,0,0,128", color.RGBA{0, 0, 0, 128}},
+		{"255,0,0,51", color.RGBA{51, 0, 0, 51}},
+		{"nope", color.RGBA{1, 2, 3, 255}},
+	}
+
+	for _, tt := range tests {
+		if got := getImageColor(tt.input, []int{1, 2, 3}); got != tt.want {
+			t.Errorf("getImageColor(%q) got %v want %v", tt.input, got, tt.want)
+		}
+	}
+}
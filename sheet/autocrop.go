@@ -0,0 +1,109 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// cropSamples is the number of frames sampled to detect black borders.
+	cropSamples = 5
+	// cropLuma is the brightness up to which a pixel belongs to a border,
+	// black bars are usually encoded at 16.
+	cropLuma = 32
+	// cropNoise is the percentage of brighter pixels tolerated in a border
+	// row or column, e.g. from compression artifacts.
+	cropNoise = 2
+)
+
+// cropSource crops all frames of a FrameSource to rect.
+type cropSource struct {
+	FrameSource
+	rect image.Rectangle
+}
+
+func (s cropSource) Image(ms int64) (image.Image, error) {
+	img, err := s.FrameSource.Image(ms)
+	if err != nil {
+		return nil, err
+	}
+	return imaging.Crop(img, s.rect), nil
+}
+
+// returns src cropped to the detected active picture, src is returned as is
+// if autocrop is disabled or no borders were detected
+func (r *run) cropped(src FrameSource) FrameSource {
+	if r.crop.Empty() {
+		return src
+	}
+	return cropSource{FrameSource: src, rect: r.crop}
+}
+
+// samples frames spread over the video and returns the smallest rectangle
+// containing the active picture of all of them, constant black borders are
+// outside of it. Returns an empty rectangle if there are no borders.
+func (r *run) detectCrop(ctx context.Context) (image.Rectangle, error) {
+	full := image.Rect(0, 0, r.info.Width, r.info.Height)
+
+	var active image.Rectangle
+	for i := 1; i <= cropSamples; i++ {
+		if err := ctx.Err(); err != nil {
+			return image.Rectangle{}, err
+		}
+
+		stamp := r.info.Duration * int64(i) / (cropSamples + 1)
+		img, err := r.src.Image(stamp)
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("%w: can't sample frame: %v", ErrUnreadableMedia, err)
+		}
+
+		rect := activeRect(img)
+		r.log.Debugf("active picture at %s: %v", formatTimestamp(stamp), rect)
+		// completely dark frames tell nothing about the borders
+		if !rect.Empty() {
+			active = active.Union(rect)
+		}
+	}
+
+	if active.Empty() || active == full {
+		r.log.Infof("no black borders detected")
+		return image.Rectangle{}, nil
+	}
+	r.log.Infof("black borders detected, cropping %dx%d to %dx%d at %d,%d", full.Dx(), full.Dy(), active.Dx(), active.Dy(), active.Min.X, active.Min.Y)
+	return active, nil
+}
+
+// returns the part of img inside its dark borders
+func activeRect(img image.Image) image.Rectangle {
+	grey := imaging.Grayscale(img)
+	b := grey.Bounds()
+
+	// dark reports if the pixels from (x, y) stepping by dx, dy are dark
+	dark := func(x, y, dx, dy, n int) bool {
+		bright := 0
+		for i := 0; i < n; i++ {
+			if grey.Pix[grey.PixOffset(x+i*dx, y+i*dy)] > cropLuma {
+				bright++
+			}
+		}
+		return bright*100 <= n*cropNoise
+	}
+
+	rect := image.Rect(0, 0, b.Dx(), b.Dy())
+	for rect.Min.Y < rect.Max.Y && dark(0, rect.Min.Y, 1, 0, b.Dx()) {
+		rect.Min.Y++
+	}
+	for rect.Max.Y > rect.Min.Y && dark(0, rect.Max.Y-1, 1, 0, b.Dx()) {
+		rect.Max.Y--
+	}
+	for rect.Min.X < rect.Max.X && dark(rect.Min.X, rect.Min.Y, 0, 1, rect.Dy()) {
+		rect.Min.X++
+	}
+	for rect.Max.X > rect.Min.X && dark(rect.Max.X-1, rect.Min.Y, 0, 1, rect.Dy()) {
+		rect.Max.X--
+	}
+	return rect
+}
@@ -0,0 +1,51 @@
+package sheet
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestActiveRect(t *testing.T) {
+	// the second scene has no dark bar at the edges
+	bars, _ := NewSyntheticSource(MediaInfo{Duration: 60000, Width: 640, Height: 280}, 60000).Image(60000)
+	letterbox := imaging.New(640, 360, color.NRGBA{16, 16, 16, 255})
+	draw.Draw(letterbox, image.Rect(0, 40, 640, 320), bars, image.ZP, draw.Src)
+
+	if got, want := activeRect(letterbox), image.Rect(0, 40, 640, 320); got != want {
+		t.Errorf("letterbox got %v want %v", got, want)
+	}
+	if got := activeRect(imaging.New(640, 360, color.Black)); !got.Empty() {
+		t.Errorf("black frame got %v, wanted empty", got)
+	}
+}
+
+func TestAutoCrop(t *testing.T) {
+	info := MediaInfo{Duration: 600000, Width: 640, Height: 360}
+	synthetic := NewSyntheticSource(MediaInfo{Duration: 600000, Width: 640, Height: 280}, 60000)
+
+	opts := syntheticOptions()
+	opts.AutoCrop = true
+	opts.OpenSource = func(input string) (FrameSource, error) {
+		return &funcSource{info: info, frame: func(ms int64) image.Image {
+			bars, _ := synthetic.Image(ms)
+			img := imaging.New(640, 360, color.Black)
+			draw.Draw(img, image.Rect(0, 40, 640, 320), bars, image.ZP, draw.Src)
+			return img
+		}}, nil
+	}
+
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	// 640x280 scaled to a width of 200
+	if got := res.Thumbnails[0].Bounds().Dy(); got != 88 {
+		t.Errorf("thumbnail height got %d want 88", got)
+	}
+}
@@ -0,0 +1,90 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+// captures Options.Candidates frames spread over Options.CandidateWindow
+// seconds around the capture point of s (limited to halfway to the
+// neighbouring slots, independent of Options.SkipDirection) and
+// returns the one with the highest frameScore. Frames which should be skipped
+// based on settings are only used if all are skipped. i is the number of the
+// slot.
+func (r *run) bestCandidate(ctx context.Context, src FrameSource, i int, s slot) (image.Image, int64, error) {
+	window := int64(r.opts.CandidateWindow) * 1000
+	start := s.stamp - window/2
+	if start < s.nearMin {
+		start = s.nearMin
+	}
+	end := start + window
+	if end > s.nearMax {
+		end = s.nearMax
+	}
+	if end > r.info.Duration {
+		end = r.info.Duration
+	}
+
+	// all candidates are captured before waiting for the thumbnails
+	// they are compared to
+	imgs := make([]image.Image, r.opts.Candidates)
+	stamps := make([]int64, r.opts.Candidates)
+	for j := range imgs {
+		stamps[j] = start + (end-start)*int64(j)/int64(r.opts.Candidates-1)
+		img, err := src.Image(stamps[j])
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: can't generate screenshot: %v", ErrUnreadableMedia, err)
+		}
+		imgs[j] = img
+	}
+	if err := r.waitAccepted(ctx, i); err != nil {
+		return nil, 0, err
+	}
+
+	var best image.Image
+	var bestStamp int64
+	bestScore := math.Inf(-1)
+	bestSkipped := true
+	for j, img := range imgs {
+		skipped := false
+		if r.opts.SkipBlank || r.opts.SkipBlurry || r.opts.SFW || r.opts.DedupeThreshold > 0 {
+			skipped = r.skipImage(img) != ""
+		}
+
+		score := frameScore(img)
+		r.log.Debugf("candidate %d/%d at %s scored %.3f (skipped: %t)", j+1, r.opts.Candidates, formatTimestamp(stamps[j]), score, skipped)
+		if (bestSkipped && !skipped) || (skipped == bestSkipped && score > bestScore) {
+			best, bestStamp, bestScore, bestSkipped = img, stamps[j], score, skipped
+		}
+	}
+
+	return best, bestStamp, nil
+}
+
+// scores img from 0 to 1 by its sharpness (using the same edge detection as
+// blur detection), brightness (best for medium brightness) and contrast
+func frameScore(img image.Image) float64 {
+	small := imaging.Resize(img, 480, 0, imaging.Box)
+	sharpness := float64(100-blurPercent(small)) / 100
+
+	grey := imaging.Grayscale(small)
+	var sum, sumSq float64
+	pixels := 0
+	for i := 0; i < len(grey.Pix); i += 4 {
+		v := float64(grey.Pix[i]) / 255
+		sum += v
+		sumSq += v * v
+		pixels++
+	}
+	mean := sum / float64(pixels)
+	stddev := math.Sqrt(math.Max(sumSq/float64(pixels)-mean*mean, 0))
+
+	brightness := 1 - math.Abs(mean-0.5)*2
+	contrast := math.Min(stddev/0.5, 1)
+
+	return (sharpness + brightness + contrast) / 3
+}
@@ -0,0 +1,94 @@
+package sheet
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// funcSource is a FrameSource which returns the frames of a function
+type funcSource struct {
+	info  MediaInfo
+	frame func(ms int64) image.Image
+}
+
+func (s *funcSource) Info() MediaInfo                     { return s.info }
+func (s *funcSource) Image(ms int64) (image.Image, error) { return s.frame(ms), nil }
+func (s *funcSource) Close() error                        { return nil }
+
+func TestFrameScore(t *testing.T) {
+	bars, _ := NewSyntheticSource(MediaInfo{Duration: 1000, Width: 640, Height: 360}, 0).Image(0)
+	grey := imaging.New(640, 360, color.NRGBA{128, 128, 128, 255})
+	black := imaging.New(640, 360, color.Black)
+
+	if frameScore(bars) <= frameScore(grey) {
+		t.Errorf("test pattern scored %.3f, not higher than flat grey %.3f", frameScore(bars), frameScore(grey))
+	}
+	if frameScore(grey) <= frameScore(black) {
+		t.Errorf("flat grey scored %.3f, not higher than black %.3f", frameScore(grey), frameScore(black))
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	info := MediaInfo{Duration: 600000, Width: 640, Height: 360}
+	bars, _ := NewSyntheticSource(info, 0).Image(0)
+	black := imaging.New(640, 360, color.Black)
+
+	opts := syntheticOptions()
+	opts.Candidates = 5
+	opts.CandidateWindow = 10
+	opts.OpenSource = func(input string) (FrameSource, error) {
+		// only one frame per capture window is worth keeping
+		return &funcSource{info: info, frame: func(ms int64) image.Image {
+			if ms%150000 == 2500 {
+				return bars
+			}
+			return black
+		}}, nil
+	}
+
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	for i, want := range []int64{152500, 302500, 452500} {
+		if res.Timestamps[i] != want {
+			t.Errorf("timestamp %d got %d want %d", i, res.Timestamps[i], want)
+		}
+	}
+}
+
+func TestCandidatesBeforeStamp(t *testing.T) {
+	info := MediaInfo{Duration: 600000, Width: 640, Height: 360}
+	bars, _ := NewSyntheticSource(info, 0).Image(0)
+	black := imaging.New(640, 360, color.Black)
+
+	opts := syntheticOptions()
+	opts.Candidates = 5
+	opts.CandidateWindow = 10
+	opts.SkipDirection = SkipForward
+	opts.OpenSource = func(input string) (FrameSource, error) {
+		// the best frame is before the capture point
+		return &funcSource{info: info, frame: func(ms int64) image.Image {
+			if ms%150000 == 147500 {
+				return bars
+			}
+			return black
+		}}, nil
+	}
+
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	for i, want := range []int64{147500, 297500, 447500} {
+		if res.Timestamps[i] != want {
+			t.Errorf("timestamp %d got %d want %d", i, res.Timestamps[i], want)
+		}
+	}
+}
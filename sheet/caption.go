@@ -0,0 +1,127 @@
+package sheet
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"strings"
+	"text/template"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// PositionTopLeft and the other positions place a caption in a corner,
+	// at the middle of an edge or in the center of a thumbnail.
+	// PositionTop, PositionBottom, PositionLeft and PositionRight also place
+	// the header.
+	PositionTopLeft     = "top-left"
+	PositionTop         = "top"
+	PositionTopRight    = "top-right"
+	PositionLeft        = "left"
+	PositionCenter      = "center"
+	PositionRight       = "right"
+	PositionBottomLeft  = "bottom-left"
+	PositionBottom      = "bottom"
+	PositionBottomRight = "bottom-right"
+)
+
+const (
+	// StyleBox draws captions on a box in the background color.
+	StyleBox = "box"
+	// StyleShadow draws captions with a drop shadow in the background color.
+	StyleShadow = "shadow"
+	// StyleOutline draws captions with an outline in the background color.
+	StyleOutline = "outline"
+)
+
+// DefaultCaption is the caption template used if Options.Caption is empty.
+const DefaultCaption = "{{.Timestamp}}"
+
+// CaptionData holds the fields available in Options.Caption.
+type CaptionData struct {
+	// Timestamp is the capture time in the format of Options.TimestampFormat.
+	Timestamp string
+	// Index is the position of the thumbnail, starting at 1.
+	Index int
+	// Total is the number of thumbnails.
+	Total int
+	// FrameNumber is the number of the captured frame based on the frame rate.
+	FrameNumber int64
+	// Percent is the position of the thumbnail in the video from 0 to 100.
+	Percent int
+	// Remaining is the time left after the capture time in the format of
+	// Options.TimestampFormat.
+	Remaining string
+}
+
+// parses Options.Caption
+func parseCaption(caption string) (*template.Template, error) {
+	if caption == "" {
+		caption = DefaultCaption
+	}
+	t, err := template.New("caption").Parse(caption)
+	if err != nil {
+		return nil, fmt.Errorf("%w: caption: %v", ErrInvalidOption, err)
+	}
+	return t, nil
+}
+
+// returns the caption of thumbnail i of total captured at stamp
+func (r *run) captionText(i, total int, stamp int64) string {
+	data := CaptionData{
+		Timestamp:   r.displayTimestamp(stamp),
+		Index:       i + 1,
+		Total:       total,
+		FrameNumber: int64(float64(stamp) * r.info.FPS / 1000),
+		Remaining:   r.displayTimestamp(r.info.Duration - stamp),
+	}
+	if r.info.Duration > 0 {
+		data.Percent = int(stamp * 100 / r.info.Duration)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := r.caption.Execute(buf, data); err != nil {
+		r.log.Errorf("error creating caption for thumbnail %d: %v", i+1, err)
+		return data.Timestamp
+	}
+	return buf.String()
+}
+
+// returns the font size of captions
+func (r *run) captionFontSize() int {
+	if r.opts.TimestampFontSize > 0 {
+		return r.opts.TimestampFontSize
+	}
+	return r.opts.FontSize
+}
+
+// draws caption onto img, this is used for every filter so captions look
+// the same whether they are drawn before or after filtering
+func (r *run) overlayCaption(img image.Image, caption string) image.Image {
+	text := r.drawText(caption)
+	pos := captionPosition(r.opts.TimestampPosition, r.opts.TimestampMargin, img.Bounds(), text.Bounds())
+	return imaging.Overlay(img, text, pos, r.opts.TimestampOpacity)
+}
+
+// returns where a caption of size text is drawn on a thumbnail of size img
+// for the given position and margin to the edges
+func captionPosition(position string, margin int, img, text image.Rectangle) image.Point {
+	pt := image.Pt((img.Dx()-text.Dx())/2, (img.Dy()-text.Dy())/2)
+	if position == "" {
+		position = PositionBottomRight
+	}
+	switch {
+	case strings.HasSuffix(position, "left"):
+		pt.X = margin
+	case strings.HasSuffix(position, "right"):
+		pt.X = img.Dx() - text.Dx() - margin
+	}
+	switch {
+	case strings.HasPrefix(position, "top"):
+		pt.Y = margin
+	case strings.HasPrefix(position, "bottom"):
+		pt.Y = img.Dy() - text.Dy() - margin
+	}
+	return pt
+}
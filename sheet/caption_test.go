@@ -0,0 +1,121 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/BurntSushi/freetype-go/freetype"
+	"github.com/mutschler/mt/internal/bindata"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestCaptionText(t *testing.T) {
+	tests := []struct {
+		caption string
+		i       int
+		stamp   int64
+		want    string
+	}{
+		{"", 0, 150000, "00:02:30"},
+		{"{{.Index}}/{{.Total}} {{.Timestamp}}", 1, 300000, "2/4 00:05:00"},
+		{"#{{.FrameNumber}} {{.Percent}}%", 2, 450000, "#11250 75%"},
+		{"{{.Timestamp}}{{\"\\n\"}}-{{.Remaining}}", 0, 150000, "00:02:30\n-00:07:30"},
+	}
+
+	for _, tt := range tests {
+		caption, err := parseCaption(tt.caption)
+		if err != nil {
+			t.Fatalf("parseCaption(%q) got %v, wanted nil", tt.caption, err)
+		}
+		r := &run{opts: DefaultOptions(), info: MediaInfo{Duration: 600000, FPS: 25}, caption: caption}
+		if got := r.captionText(tt.i, 4, tt.stamp); got != tt.want {
+			t.Errorf("captionText(%q) got %q want %q", tt.caption, got, tt.want)
+		}
+	}
+}
+
+func TestDrawTextLines(t *testing.T) {
+	fontBytes, err := bindata.GetFont(DefaultOptions().Font)
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := freetype.ParseFont(fontBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &run{opts: DefaultOptions(), font: font, log: log.NewEntry(log.StandardLogger())}
+
+	one := r.drawText("00:02:30").Bounds()
+	two := r.drawText("00:02:30\n00:02:30").Bounds()
+	if two.Dx() != one.Dx() {
+		t.Errorf("width got %d want %d", two.Dx(), one.Dx())
+	}
+	if two.Dy() <= one.Dy() {
+		t.Errorf("height of two lines got %d, wanted more than %d", two.Dy(), one.Dy())
+	}
+}
+
+func TestDrawTextStyle(t *testing.T) {
+	fontBytes, err := bindata.GetFont(DefaultOptions().Font)
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := freetype.ParseFont(fontBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, style := range []string{StyleBox, StyleShadow, StyleOutline} {
+		opts := DefaultOptions()
+		opts.TimestampStyle = style
+		r := &run{opts: opts, font: font, log: log.NewEntry(log.StandardLogger())}
+		_, _, _, a := r.drawText("00:02:30").At(0, 0).RGBA()
+		if boxed := a != 0; boxed != (style == StyleBox) {
+			t.Errorf("style %s got a box %t", style, boxed)
+		}
+	}
+}
+
+func TestCaptionPosition(t *testing.T) {
+	img, text := image.Rect(0, 0, 200, 100), image.Rect(0, 0, 50, 20)
+	tests := []struct {
+		position string
+		want     image.Point
+	}{
+		{"", image.Pt(140, 70)},
+		{PositionBottomRight, image.Pt(140, 70)},
+		{PositionTopLeft, image.Pt(10, 10)},
+		{PositionTop, image.Pt(75, 10)},
+		{PositionRight, image.Pt(140, 40)},
+		{PositionCenter, image.Pt(75, 40)},
+		{PositionBottomLeft, image.Pt(10, 70)},
+	}
+
+	for _, tt := range tests {
+		if got := captionPosition(tt.position, 10, img, text); got != tt.want {
+			t.Errorf("captionPosition(%q) got %v want %v", tt.position, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateInvalidCaption(t *testing.T) {
+	opts := syntheticOptions()
+	opts.Caption = "{{.Timestamp"
+	if _, err := Generate(context.Background(), "synthetic.mkv", opts); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("got %v, wanted ErrInvalidOption", err)
+	}
+
+	opts = syntheticOptions()
+	opts.TimestampPosition = "middle"
+	if _, err := Generate(context.Background(), "synthetic.mkv", opts); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("position got %v, wanted ErrInvalidOption", err)
+	}
+
+	opts = syntheticOptions()
+	opts.TimestampStyle = "glow"
+	if _, err := Generate(context.Background(), "synthetic.mkv", opts); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("style got %v, wanted ErrInvalidOption", err)
+	}
+}
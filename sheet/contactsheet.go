@@ -0,0 +1,293 @@
+package sheet
+
+import (
+	"image"
+	"image/draw"
+	"strings"
+
+	"github.com/BurntSushi/freetype-go/freetype"
+	"github.com/disintegration/imaging"
+)
+
+// draws text, which may span several lines, in Options.TimestampStyle and
+// returns it, the image is transparent outside of the box
+func (r *run) drawText(text string) image.Image {
+	fg, bg := image.NewUniform(r.opts.FgTimestamp), image.NewUniform(r.opts.BgTimestamp)
+	size := float64(r.captionFontSize())
+	c := freetype.NewContext()
+	c.SetDPI(72)
+	c.SetFont(r.font)
+	c.SetFontSize(size)
+
+	// get width and height of the lines and draw an image to hold them
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	ascent := int(c.PointToFix32(size) >> 8)
+	lineHeight := ascent + 2
+	width := 0
+	for _, line := range lines {
+		x, _, _ := c.MeasureString(line)
+		if int(x)/256 > width {
+			width = int(x) / 256
+		}
+	}
+	_, y, _ := c.MeasureString(lines[0])
+	rgba := image.NewRGBA(image.Rect(0, 0, width+10, int(y)/256+lineHeight*(len(lines)-1)+10))
+	c.SetClip(rgba.Bounds())
+	c.SetDst(rgba)
+
+	// the text is drawn in the background color at these offsets first
+	var offsets []image.Point
+	d := int(size) / 12
+	if d < 1 {
+		d = 1
+	}
+	switch r.opts.TimestampStyle {
+	case StyleShadow:
+		offsets = []image.Point{{d, d}}
+	case StyleOutline:
+		for dy := -d; dy <= d; dy += d {
+			for dx := -d; dx <= d; dx += d {
+				if dx != 0 || dy != 0 {
+					offsets = append(offsets, image.Pt(dx, dy))
+				}
+			}
+		}
+	default:
+		draw.Draw(rgba, rgba.Bounds(), bg, image.ZP, draw.Src)
+	}
+
+	//draw the text with 5px padding
+	drawLines := func(off image.Point) {
+		for i, line := range lines {
+			pt := freetype.Pt(5+off.X, 3+ascent+lineHeight*i+off.Y)
+			if _, err := c.DrawString(line, pt); err != nil {
+				r.log.Errorf("error creating caption image for: %s", text)
+			}
+		}
+	}
+	c.SetSrc(bg)
+	for _, off := range offsets {
+		drawLines(off)
+	}
+	c.SetSrc(fg)
+	drawLines(image.Point{})
+
+	r.log.Debugf("created caption image for: %q", text)
+
+	return rgba
+}
+
+// composes the thumbnails of res into res.Pages
+func (r *run) makeContactSheet(res *Result) {
+	r.log.Info("Composing Contact Sheet")
+	pages := r.pages(len(res.Thumbnails))
+	r.pageCount = len(pages)
+	for i, pg := range pages {
+		if len(pages) > 1 {
+			r.log.Infof("composing page %d/%d", i+1, len(pages))
+		}
+		res.Pages = append(res.Pages, r.composePage(res, pg, i))
+	}
+	res.Sheet = res.Pages[0]
+}
+
+// composes the thumbnails of pg into the contact sheet image of page number i
+func (r *run) composePage(res *Result, pg page, i int) image.Image {
+	thumbs := res.Thumbnails[pg.first:pg.last]
+	hero := -1
+	if r.hero >= pg.first && r.hero < pg.last {
+		hero = r.hero - pg.first
+	}
+
+	// the size of a grid cell, taken from a thumbnail which isn't featured
+	// or from the cells the featured one covers if it is alone on the page
+	ref := 0
+	if ref == hero && len(thumbs) > 1 {
+		ref = 1
+	}
+	imgWidth := thumbs[ref].Bounds().Dx()
+	imgHeight := thumbs[ref].Bounds().Dy()
+	if ref == hero {
+		span := r.heroSpan()
+		imgWidth = (imgWidth - (span-1)*r.opts.Padding) / span
+		imgHeight = (imgHeight - (span-1)*r.opts.Padding) / span
+	}
+
+	cells := r.cells(len(thumbs), hero)
+	columns, imgRows, usedColumns := r.columns, 0, 0
+	for _, cell := range cells {
+		if cell.Max.Y > imgRows {
+			imgRows = cell.Max.Y
+		}
+		if cell.Max.X > usedColumns {
+			usedColumns = cell.Max.X
+		}
+	}
+	// column by column the last columns of a page may stay empty
+	if r.opts.Order == OrderColumns {
+		columns = usedColumns
+	}
+
+	// filters may have made the thumbnails larger than fitSheet planned
+	if scale := r.pageScale(imgWidth, imgHeight, columns, imgRows); scale < 1 {
+		r.log.Infof("filters changed the thumbnail size, shrinking them by %.2f to fit the %dx%d sheet", scale, r.opts.SheetWidth, r.opts.SheetHeight)
+		imgWidth = int(float64(imgWidth) * scale)
+		imgHeight = int(float64(imgHeight) * scale)
+		thumbs = append([]image.Image(nil), thumbs...)
+		for j, thumb := range thumbs {
+			width := int(float64(thumb.Bounds().Dx()) * scale)
+			height := int(float64(thumb.Bounds().Dy()) * scale)
+			if j == hero {
+				// the featured thumbnail has to cover its cells exactly
+				span := r.heroSpan()
+				width = span*imgWidth + (span-1)*r.opts.Padding
+				height = span*imgHeight + (span-1)*r.opts.Padding
+			}
+			thumbs[j] = imaging.Resize(thumb, maxInt(width, 1), maxInt(height, 1), imaging.Lanczos)
+		}
+	}
+
+	r.log.Debugf("single image dimension: %dx%d", imgWidth, imgHeight)
+	r.log.Debugf("new image dimension: %dx%d", imgWidth*columns, imgHeight*imgRows)
+
+	paddingColumns := 0
+	singlepadd := 0
+	paddingRows := 0
+	if r.opts.Padding > 0 {
+		paddingColumns = (columns + 1) * r.opts.Padding
+		paddingRows = (imgRows + 1) * r.opts.Padding
+		singlepadd = r.opts.Padding
+	}
+
+	// create a new blank image, at least as large as Options.SheetWidth
+	// and Options.SheetHeight with the thumbnails centered
+	gridWidth := imgWidth*columns + paddingColumns
+	gridHeight := imgHeight*imgRows + paddingRows
+	minWidth, minHeight := r.opts.SheetWidth, r.opts.SheetHeight
+	if r.sidebar() {
+		// a sidebar is as wide as a thumbnail
+		minWidth -= imgWidth
+	}
+	sheetWidth := maxInt(gridWidth, minWidth)
+
+	var head image.Image
+	if r.opts.Header {
+		r.log.Info("creating header information")
+		if r.sidebar() {
+			head = r.appendHeader(imgWidth, maxInt(gridHeight, minHeight), i)
+		} else {
+			head = r.appendHeader(sheetWidth, 0, i)
+			minHeight -= head.Bounds().Dy()
+		}
+	}
+
+	sheetHeight := maxInt(gridHeight, minHeight)
+	if r.sidebar() {
+		// the sidebar may be taller than the thumbnails if it has many lines
+		sheetHeight = head.Bounds().Dy()
+	}
+	offset := image.Pt((sheetWidth-gridWidth)/2, (sheetHeight-gridHeight)/2)
+
+	// the position of the thumbnails and of the header on the page
+	var content, headPos image.Point
+	if r.opts.Header {
+		switch r.opts.HeaderPosition {
+		case PositionBottom:
+			headPos = image.Pt(0, sheetHeight)
+		case PositionLeft:
+			content = image.Pt(head.Bounds().Dx(), 0)
+		case PositionRight:
+			headPos = image.Pt(sheetWidth, 0)
+		default:
+			content = image.Pt(0, head.Bounds().Dy())
+		}
+	}
+
+	bgColor := r.opts.BgContent
+	dst := imaging.New(sheetWidth, sheetHeight, bgColor)
+
+	// paste thumbnails into their cells with padding if enabled
+	for j, thumb := range thumbs {
+		xPos := cells[j].Min.X*(imgWidth+singlepadd) + singlepadd + offset.X
+		yPos := cells[j].Min.Y*(imgHeight+singlepadd) + singlepadd + offset.Y
+		dst = imaging.Paste(dst, thumb, image.Pt(xPos, yPos))
+
+		res.cues = append(res.cues, cue{
+			page: i,
+			rect: image.Rect(xPos, yPos, xPos+thumb.Bounds().Dx(), yPos+thumb.Bounds().Dy()).Add(content),
+		})
+	}
+
+	if r.opts.Header {
+		size := dst.Bounds().Size().Add(head.Bounds().Size())
+		if r.sidebar() {
+			size.Y = dst.Bounds().Dy()
+		} else {
+			size.X = dst.Bounds().Dx()
+		}
+		newIm := imaging.New(size.X, size.Y, bgColor)
+		dst = imaging.Paste(newIm, dst, content)
+		dst = imaging.Paste(dst, head, headPos)
+	}
+
+	return dst
+}
+
+// returns the height of the header image with the given width in pixels, 0
+// if it is disabled or a sidebar which doesn't add to the height
+func (r *run) headerHeight(width int) int {
+	if !r.opts.Header || r.sidebar() {
+		return 0
+	}
+	return r.layoutHeader(width, 0).height
+}
+
+// returns the lines of the left and right header column, they are only
+// created once
+func (r *run) headerLines() ([]string, []string) {
+	if !r.headerCreated {
+		r.header = r.createHeader(r.headerTmpls)
+		r.headerRight = r.createHeader(r.headerRightTmpls)
+		r.headerCreated = true
+	}
+	return r.header, r.headerRight
+}
+
+// creates the header image of page number page with the given width which
+// is at least minHeight high
+func (r *run) appendHeader(width, minHeight, page int) image.Image {
+	fontcolor, bg := image.NewUniform(r.opts.FgHeader), image.NewUniform(r.opts.BgHeader)
+	c := r.headerContext()
+	block := r.layoutHeader(width, page)
+
+	rgba := image.NewNRGBA(image.Rect(0, 0, width, maxInt(block.height, minHeight)))
+	draw.Draw(rgba, rgba.Bounds(), bg, image.ZP, draw.Src)
+	if ov := block.image; ov != nil {
+		//center image inside header
+		posY := (rgba.Bounds().Dy() - ov.Bounds().Dy()) / 2
+		if posY < 10 {
+			posY = 10
+		}
+		rgba = imaging.Overlay(rgba, ov, image.Pt(rgba.Bounds().Dx()-ov.Bounds().Dx()-10, posY), 1.0)
+	}
+
+	c.SetClip(rgba.Bounds())
+	c.SetDst(rgba)
+	c.SetSrc(fontcolor)
+
+	lineHeight := r.headerLineHeight()
+	drawColumn := func(x int, lines []string) {
+		for i, s := range lines {
+			//draw the text with 10px padding and lineheight +4
+			pt := freetype.Pt(x, 5+lineHeight*(i+1))
+			if _, err := c.DrawString(s, pt); err != nil {
+				r.log.Errorf("error drawing header line %q: %v", s, err)
+				break
+			}
+		}
+	}
+	drawColumn(10, block.left)
+	drawColumn(block.x, block.right)
+
+	return rgba
+}
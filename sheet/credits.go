@@ -0,0 +1,139 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"image"
+)
+
+const (
+	// DefaultCreditsBlankThreshold is the default percentage of dark or
+	// white pixels above which a frame may belong to intro or credits.
+	DefaultCreditsBlankThreshold = 80
+	// DefaultCreditsBlurThreshold is the default percentage of pixels
+	// without edges above which a frame may belong to intro or credits.
+	DefaultCreditsBlurThreshold = 90
+)
+
+const (
+	// creditsScan is the longest part at the end of a video scanned for
+	// credits, introScan the longest part at the start scanned for an intro.
+	creditsScan = 15 * 60000
+	introScan   = 5 * 60000
+	// boundarySamples is the number of frames sampled per scanned part.
+	boundarySamples = 60
+	// boundaryGap is the number of content frames tolerated inside intro or
+	// credits, e.g. a short scene between two credit cards.
+	boundaryGap = 2
+)
+
+// returns whether img looks like intro or credits: text on a dark (or plain)
+// background with hardly any edges
+func (r *run) isCreditsFrame(img image.Image) (bool, int, int) {
+	blank := blankPercent(img)
+	blur := blurPercent(img)
+	return blank >= r.opts.CreditsBlankThreshold && blur >= r.opts.CreditsBlurThreshold, blank, blur
+}
+
+// samples the video from start towards stop and returns the timestamp of the
+// last intro or credits frame of the run beginning at start, ok is false if
+// none of the first samples looks like intro or credits
+func (r *run) scanBoundary(ctx context.Context, start, stop int64) (int64, bool, error) {
+	step := (stop - start) / boundarySamples
+	if step < 0 {
+		step = -step
+	}
+	if step < 1000 {
+		step = 1000
+	}
+	if stop < start {
+		step = -step
+	}
+
+	last, ok := start, false
+	gap := 0
+	for stamp := start; (step > 0 && stamp <= stop) || (step < 0 && stamp >= stop); stamp += step {
+		if err := ctx.Err(); err != nil {
+			return 0, false, err
+		}
+
+		img, err := r.src.Image(stamp)
+		if err != nil {
+			return 0, false, fmt.Errorf("%w: can't sample frame: %v", ErrUnreadableMedia, err)
+		}
+
+		credits, blank, blur := r.isCreditsFrame(img)
+		r.log.Debugf("boundary sample at %s: blank %d, blur %d, credits %t", formatTimestamp(stamp), blank, blur, credits)
+		if !credits {
+			gap++
+			if gap > boundaryGap {
+				break
+			}
+			continue
+		}
+		last, ok = stamp, true
+		gap = 0
+	}
+	return last, ok, nil
+}
+
+// returns the timestamp at which the credits at the end of a video of the
+// given duration start, or duration if no credits were detected. Only the
+// part after from is scanned.
+func (r *run) detectCredits(ctx context.Context, from, duration int64) (int64, error) {
+	scan := duration / 4
+	if scan > creditsScan {
+		scan = creditsScan
+	}
+	if scan > duration-from {
+		scan = duration - from
+	}
+	if scan <= 1000 {
+		return duration, nil
+	}
+
+	// the very last frame is often black, start a little earlier
+	start, ok, err := r.scanBoundary(ctx, duration-1000, duration-scan)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		r.log.Infof("no credits detected in the last %s", formatTimestamp(scan))
+		return duration, nil
+	}
+	r.log.Infof("credits detected from %s to %s", formatTimestamp(start), formatTimestamp(duration))
+	return start, nil
+}
+
+// returns the timestamp at which the content after the intro of a video of
+// the given duration starts, or 0 if no intro was detected. Only the part
+// before end is scanned.
+func (r *run) detectIntro(ctx context.Context, duration, end int64) (int64, error) {
+	scan := duration / 4
+	if scan > introScan {
+		scan = introScan
+	}
+	if scan > end-1 {
+		scan = end - 1
+	}
+	if scan < 0 {
+		return 0, nil
+	}
+
+	end, ok, err := r.scanBoundary(ctx, 0, scan)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		r.log.Infof("no intro detected in the first %s", formatTimestamp(scan))
+		return 0, nil
+	}
+
+	// the content starts with the first frame after the intro
+	step := scan / boundarySamples
+	if step < 1000 {
+		step = 1000
+	}
+	r.log.Infof("intro detected from 00:00:00 to %s", formatTimestamp(end+step))
+	return end + step, nil
+}
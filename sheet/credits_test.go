@@ -0,0 +1,111 @@
+package sheet
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// returns a frame with a few lines of white "text" on black
+func creditsFrame() image.Image {
+	img := imaging.New(640, 360, color.Black)
+	for y := 100; y < 260; y += 40 {
+		draw.Draw(img, image.Rect(220, y, 420, y+8), image.White, image.ZP, draw.Src)
+	}
+	return img
+}
+
+func TestIsCreditsFrame(t *testing.T) {
+	r := &run{opts: DefaultOptions()}
+	bars, _ := NewSyntheticSource(MediaInfo{Duration: 1000, Width: 640, Height: 360}, 0).Image(0)
+
+	if ok, blank, blur := r.isCreditsFrame(creditsFrame()); !ok {
+		t.Errorf("credits frame (blank %d, blur %d) not detected", blank, blur)
+	}
+	if ok, blank, blur := r.isCreditsFrame(bars); ok {
+		t.Errorf("test pattern (blank %d, blur %d) detected as credits", blank, blur)
+	}
+}
+
+func TestSkipIntroCredits(t *testing.T) {
+	info := MediaInfo{Duration: 600000, Width: 640, Height: 360}
+	bars, _ := NewSyntheticSource(info, 0).Image(0)
+	credits := creditsFrame()
+
+	opts := syntheticOptions()
+	opts.SkipIntro = true
+	opts.SkipCredits = true
+	opts.OpenSource = func(input string) (FrameSource, error) {
+		// a 30 second intro and 100 seconds of credits
+		return &funcSource{info: info, frame: func(ms int64) image.Image {
+			if ms < 30000 || ms >= 500000 {
+				return credits
+			}
+			return bars
+		}}, nil
+	}
+
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	for i, stamp := range res.Timestamps {
+		if stamp < 30000 || stamp >= 500000 {
+			t.Errorf("timestamp %d at %d is inside intro or credits", i, stamp)
+		}
+	}
+	if first := res.Timestamps[0]; first > 60000 {
+		t.Errorf("first timestamp got %d, wanted close to the end of the intro at 30000", first)
+	}
+}
+
+func TestSkipIntroCreditsRange(t *testing.T) {
+	info := MediaInfo{Duration: 600000, Width: 640, Height: 360}
+	bars, _ := NewSyntheticSource(info, 0).Image(0)
+	credits := creditsFrame()
+
+	tests := []struct {
+		from, to   string
+		start, end int64
+	}{
+		// credits from 08:20 start before from
+		{"90%", "00:00:00", 540000, 600000},
+		// the intro lasts past to
+		{"00:00:00", "00:00:40", 0, 40000},
+	}
+
+	for _, tt := range tests {
+		opts := syntheticOptions()
+		opts.SkipIntro = true
+		opts.SkipCredits = true
+		opts.From, opts.To = tt.from, tt.to
+		opts.OpenSource = func(input string) (FrameSource, error) {
+			// a 60 second intro and credits from 08:20
+			return &funcSource{info: info, frame: func(ms int64) image.Image {
+				if ms < 60000 || ms >= 500000 {
+					return credits
+				}
+				return bars
+			}}, nil
+		}
+
+		res, err := Generate(context.Background(), "synthetic.mkv", opts)
+		if err != nil {
+			t.Fatalf("from %s to %s got %v, wanted nil", tt.from, tt.to, err)
+		}
+		for i, stamp := range res.Timestamps {
+			if stamp < tt.start || stamp > tt.end {
+				t.Errorf("from %s to %s: timestamp %d at %d is outside of %d-%d", tt.from, tt.to, i, stamp, tt.start, tt.end)
+			}
+			if i > 0 && stamp <= res.Timestamps[i-1] {
+				t.Errorf("from %s to %s: timestamps %v are not ascending", tt.from, tt.to, res.Timestamps)
+				break
+			}
+		}
+	}
+}
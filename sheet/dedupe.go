@@ -0,0 +1,83 @@
+package sheet
+
+import (
+	"context"
+	"image"
+	"math/bits"
+
+	"github.com/disintegration/imaging"
+)
+
+// acceptedFrame is a frame used as thumbnail, remembered to detect duplicates
+type acceptedFrame struct {
+	hash  uint64
+	stamp int64
+}
+
+// returns the difference hash (dHash) of img, similar images have hashes
+// with a small hamming distance
+func dHash(img image.Image) uint64 {
+	small := imaging.Grayscale(imaging.Resize(img, 9, 8, imaging.Box))
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			left := small.Pix[small.PixOffset(x, y)]
+			right := small.Pix[small.PixOffset(x+1, y)]
+			hash <<= 1
+			if left < right {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// returns the number of bits which differ in a and b
+func hammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// decides if img is within Options.DedupeThreshold of an accepted thumbnail
+func (r *run) isDuplicate(img image.Image) bool {
+	hash := dHash(img)
+
+	r.acceptedMu.Lock()
+	defer r.acceptedMu.Unlock()
+	for _, frame := range r.accepted {
+		distance := hammingDistance(hash, frame.hash)
+		r.log.Debugf("hash distance to thumbnail at %s is %d", formatTimestamp(frame.stamp), distance)
+		if distance <= r.opts.DedupeThreshold {
+			r.log.Debugf("image is a near duplicate of thumbnail at %s, dropping frame", formatTimestamp(frame.stamp))
+			return true
+		}
+	}
+	return false
+}
+
+// remembers img captured at stamp as thumbnail of slot i for duplicate
+// detection
+func (r *run) acceptFrame(img image.Image, stamp int64, i int) {
+	hash := dHash(img)
+
+	r.acceptedMu.Lock()
+	r.accepted = append(r.accepted, acceptedFrame{hash: hash, stamp: stamp})
+	r.acceptedMu.Unlock()
+	if r.acceptedDone != nil {
+		close(r.acceptedDone[i])
+	}
+}
+
+// waits until the thumbnails of all slots before slot i are accepted, so
+// frames are only compared to the thumbnails before them in sheet order no
+// matter how the jobs are scheduled
+func (r *run) waitAccepted(ctx context.Context, i int) error {
+	if r.acceptedDone == nil || i == 0 {
+		return nil
+	}
+	select {
+	case <-r.acceptedDone[i-1]:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,78 @@
+package sheet
+
+import (
+	"context"
+	"image"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDHash(t *testing.T) {
+	src := NewSyntheticSource(MediaInfo{Duration: 600000, Width: 640, Height: 360}, 60000)
+	first, _ := src.Image(10000)
+	sameScene, _ := src.Image(50000)
+	nextScene, _ := src.Image(70000)
+
+	if d := hammingDistance(dHash(first), dHash(sameScene)); d > 4 {
+		t.Errorf("distance within a scene got %d, wanted at most 4", d)
+	}
+	if d := hammingDistance(dHash(first), dHash(nextScene)); d <= 4 {
+		t.Errorf("distance between scenes got %d, wanted more than 4", d)
+	}
+}
+
+func TestDedupeThreshold(t *testing.T) {
+	opts := syntheticOptions()
+	opts.DedupeThreshold = 4
+	opts.OpenSource = func(input string) (FrameSource, error) {
+		// a single scene, every frame is a near duplicate of the first one
+		return NewSyntheticSource(MediaInfo{Duration: 600000, Width: 640, Height: 360}, 0), nil
+	}
+
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	// the first capture is accepted, the second one is retried three times
+	if res.Timestamps[0] != 150000 {
+		t.Errorf("timestamp 0 got %d want 150000", res.Timestamps[0])
+	}
+	if res.Timestamps[1] != 330000 {
+		t.Errorf("timestamp 1 got %d want 330000", res.Timestamps[1])
+	}
+}
+
+func TestDedupeJobsOrder(t *testing.T) {
+	info := MediaInfo{Duration: 600000, Width: 640, Height: 360, FPS: 25}
+	generate := func(jobs int) []int64 {
+		opts := syntheticOptions()
+		opts.Numcaps = 8
+		opts.DedupeThreshold = 4
+		opts.Jobs = jobs
+		opts.OpenSource = func(input string) (FrameSource, error) {
+			// two capture points per scene, early frames are slow so later
+			// slots would finish first without ordering
+			src := NewSyntheticSource(info, 150000)
+			return &funcSource{info: info, frame: func(ms int64) image.Image {
+				time.Sleep(time.Duration(600000-ms) * time.Millisecond / 60000)
+				img, _ := src.Image(ms)
+				return img
+			}}, nil
+		}
+
+		res, err := Generate(context.Background(), "synthetic.mkv", opts)
+		if err != nil {
+			t.Fatalf("jobs %d got %v, wanted nil", jobs, err)
+		}
+		return res.Timestamps
+	}
+
+	want := generate(1)
+	for i := 0; i < 3; i++ {
+		if got := generate(4); !reflect.DeepEqual(got, want) {
+			t.Errorf("run %d with 4 jobs got %v, wanted %v like 1 job", i+1, got, want)
+		}
+	}
+}
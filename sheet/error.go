@@ -0,0 +1,19 @@
+package sheet
+
+import "errors"
+
+var (
+	// ErrUnreadableMedia occurs when a video file cannot be opened or a frame
+	// cannot be decoded from it.
+	ErrUnreadableMedia = errors.New("unreadable media")
+	// ErrIntervalTooLong occurs when the capture interval is longer than the
+	// video.
+	ErrIntervalTooLong = errors.New("interval is longer than video duration")
+	// ErrInvalidRange occurs when the capture range (from/to) or a timestamp of
+	// Options.At is invalid.
+	ErrInvalidRange = errors.New("invalid capture range")
+	// ErrInvalidTimestamp occurs when a timestamp can't be parsed.
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+	// ErrInvalidOption occurs when an option has an unknown value.
+	ErrInvalidOption = errors.New("invalid option")
+)
@@ -0,0 +1,418 @@
+package sheet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/freetype-go/freetype"
+	"github.com/disintegration/imaging"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	// HeaderWrap breaks header lines which are too long for the header at
+	// spaces, or anywhere if a single word doesn't fit.
+	HeaderWrap = "wrap"
+	// HeaderEllipsis cuts header lines which are too long for the header
+	// off with "...".
+	HeaderEllipsis = "ellipsis"
+)
+
+// DefaultHeaderLines are the header lines used if Options.HeaderLines is
+// empty, followed by HeaderMetaLines if Options.HeaderMeta is set and the
+// comment. With two header columns the file name and size stay on the left
+// and the other lines are the right column unless
+// Options.HeaderRightLines is set.
+var DefaultHeaderLines = []string{
+	"File Name: {{.Name}}",
+	"File Size: {{.Size}}",
+	"Duration: {{.Duration}}",
+	"Resolution: {{.Resolution}}{{if .Cropped}} (cropped to {{.Cropped}}){{end}}",
+}
+
+// HeaderMetaLines are the header lines added by Options.HeaderMeta.
+var HeaderMetaLines = []string{
+	`FPS: {{printf "%.2f" .FPS}}, Bitrate: {{.Bitrate}}Kbp/s`,
+	"Codec: {{.VideoCodec}} / {{.AudioCodec}}",
+}
+
+// HeaderData holds the fields available in Options.HeaderLines.
+type HeaderData struct {
+	// Name is the file name of the video.
+	Name string
+	// Path is the path or URL of the video as given to Generate.
+	Path string
+	// Size is the readable file size like "1.2 GiB", "unknown" if it can't
+	// be determined.
+	Size string
+	// SizeBytes is the file size in bytes, 0 if it is unknown.
+	SizeBytes int64
+	// Container is the file extension of the video without the dot.
+	Container string
+	// Modified is the modification time of the video, zero if it is unknown.
+	Modified time.Time
+	// Duration is the duration in the format of Options.TimestampFormat.
+	Duration string
+	// DurationMS is the duration in milliseconds.
+	DurationMS int64
+	// Width and Height are the size of the video in pixels.
+	Width, Height int
+	// Resolution is the size of the video like "1920x1080".
+	Resolution string
+	// Cropped is the size after removing black borders like "1920x800",
+	// empty if Options.AutoCrop is disabled or found no borders.
+	Cropped string
+	// FPS is the number of frames per second.
+	FPS float64
+	// Bitrate is the bitrate in kbit/s.
+	Bitrate int
+	// VideoCodec and AudioCodec are the readable names of the codecs.
+	VideoCodec, AudioCodec string
+	// Comment is Options.Comment.
+	Comment string
+
+	hash string
+}
+
+// Hash returns the hex encoded SHA-256 of the video, it is only calculated
+// if a header line uses it. Returns "unknown" for videos which aren't local
+// files.
+func (d *HeaderData) Hash() string {
+	if d.hash != "" {
+		return d.hash
+	}
+	d.hash = "unknown"
+
+	f, err := os.Open(d.Path)
+	if err != nil {
+		return d.hash
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err == nil {
+		d.hash = hex.EncodeToString(h.Sum(nil))
+	}
+	return d.hash
+}
+
+// parses the header lines of opts for the left and the right column
+func parseHeader(opts Options) ([]*template.Template, []*template.Template, error) {
+	left := opts.HeaderLines
+	var right []string
+	if opts.HeaderColumns == 2 {
+		right = opts.HeaderRightLines
+	}
+	if len(left) == 0 {
+		if opts.HeaderColumns == 2 {
+			left = append(left, DefaultHeaderLines[:2]...)
+			if len(right) == 0 {
+				right = append(right, DefaultHeaderLines[2:]...)
+				if opts.HeaderMeta {
+					right = append(right, HeaderMetaLines...)
+				}
+			}
+		} else {
+			left = append(left, DefaultHeaderLines...)
+			if opts.HeaderMeta {
+				left = append(left, HeaderMetaLines...)
+			}
+		}
+		left = append(left, "{{.Comment}}")
+	}
+
+	parse := func(lines []string, column string) ([]*template.Template, error) {
+		tmpls := make([]*template.Template, len(lines))
+		for i, line := range lines {
+			t, err := template.New(fmt.Sprintf("%sheader line %d", column, i+1)).Parse(line)
+			if err != nil {
+				return nil, fmt.Errorf("%w: header: %v", ErrInvalidOption, err)
+			}
+			tmpls[i] = t
+		}
+		return tmpls, nil
+	}
+	leftTmpls, err := parse(left, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	rightTmpls, err := parse(right, "right ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return leftTmpls, rightTmpls, nil
+}
+
+// returns the lines of text created by the header templates tmpls, lines
+// which are empty are left out
+func (r *run) createHeader(tmpls []*template.Template) []string {
+	if len(tmpls) == 0 {
+		return nil
+	}
+	data := r.headerData()
+
+	var header []string
+	for _, t := range tmpls {
+		buf := new(bytes.Buffer)
+		if err := t.Execute(buf, data); err != nil {
+			r.log.Errorf("error creating %s: %v", t.Name(), err)
+			continue
+		}
+		if buf.Len() > 0 {
+			header = append(header, strings.Split(buf.String(), "\n")...)
+		}
+	}
+	return header
+}
+
+// collects the metadata of the video shown in the header
+func (r *run) headerData() *HeaderData {
+	info := r.info
+	data := &HeaderData{
+		Path:       r.input,
+		Size:       "unknown",
+		Duration:   r.displayTimestamp(info.Duration),
+		DurationMS: info.Duration,
+		Width:      info.Width,
+		Height:     info.Height,
+		Resolution: fmt.Sprintf("%dx%d", info.Width, info.Height),
+		FPS:        info.FPS,
+		Bitrate:    info.Bitrate,
+		VideoCodec: info.VideoCodec,
+		AudioCodec: info.AudioCodec,
+		Comment:    r.opts.Comment,
+	}
+	if !r.crop.Empty() {
+		data.Cropped = fmt.Sprintf("%dx%d", r.crop.Dx(), r.crop.Dy())
+	}
+
+	fn := r.input
+	_, data.Name = filepath.Split(fn)
+
+	if stat, err := os.Stat(fn); err == nil {
+		data.SizeBytes = stat.Size()
+		data.Size = humanize.IBytes(uint64(stat.Size()))
+		data.Modified = stat.ModTime()
+	} else {
+		// try if it is a web video
+		var resp *http.Response
+		if _, err = url.ParseRequestURI(fn); err == nil {
+			resp, err = http.Head(fn)
+		}
+		if err != nil {
+			r.log.Debugf("unable to get file size of %s: %v", fn, err)
+		} else {
+			defer resp.Body.Close()
+
+			// too expensive to download the whole file if it is missing
+			if size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+				data.SizeBytes = size
+				data.Size = humanize.IBytes(uint64(size))
+			}
+			if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+				data.Modified = modified
+			}
+
+			cdisposition := resp.Header.Get("Content-Disposition")
+			_, params, _ := mime.ParseMediaType(cdisposition)
+			if params["filename"] != "" {
+				data.Name = params["filename"] // prefer filename to the name split from url
+			}
+		}
+	}
+	data.Container = strings.TrimPrefix(strings.ToLower(filepath.Ext(data.Name)), ".")
+
+	return data
+}
+
+// headerBlock is the header text laid out for a header width.
+type headerBlock struct {
+	// left and right are the lines of both columns after wrapping
+	left, right []string
+	// x is the position of the right column
+	x int
+	// height is the height of the header
+	height int
+	// image is Options.HeaderImage resized to fit the header, nil if
+	// there is none
+	image image.Image
+}
+
+// returns whether the header is a sidebar left or right of the thumbnails
+func (r *run) sidebar() bool {
+	return r.opts.Header && (r.opts.HeaderPosition == PositionLeft || r.opts.HeaderPosition == PositionRight)
+}
+
+// returns a freetype context for the header text
+func (r *run) headerContext() *freetype.Context {
+	c := freetype.NewContext()
+	c.SetDPI(96)
+	c.SetFont(r.font)
+	c.SetFontSize(float64(r.opts.FontSize))
+	return c
+}
+
+// returns the height of a header line in pixels
+func (r *run) headerLineHeight() int {
+	c := freetype.NewContext()
+	c.SetDPI(96)
+	return int(c.PointToFix32(float64(r.opts.FontSize+4)) >> 8)
+}
+
+// lays out the header of page number page for the given width, the text
+// is wrapped or ellipsized so it doesn't run under the header image
+func (r *run) layoutHeader(width, page int) headerBlock {
+	left, right := r.headerLines()
+	if r.sidebar() {
+		// a sidebar stacks the columns
+		left, right = append(left[:len(left):len(left)], right...), nil
+	}
+	if r.pageCount > 1 {
+		left = append(left[:len(left):len(left)], fmt.Sprintf("Page: %d/%d", page+1, r.pageCount))
+	}
+	lineHeight := r.headerLineHeight()
+
+	// the header image is sized by the unwrapped text so wrapping can't
+	// change the space left for the text
+	var b headerBlock
+	b.image = r.headerImage(5 + lineHeight*maxInt(len(left), len(right)) + 10 - 20)
+	space := width - 20
+	if b.image != nil {
+		space -= b.image.Bounds().Dx() + 10
+	}
+
+	c := r.headerContext()
+	measure := func(s string) int {
+		x, _, err := c.MeasureString(s)
+		if err != nil {
+			return 0
+		}
+		return int(x) / 256
+	}
+	if len(right) > 0 {
+		columnWidth := (space - 20) / 2
+		b.left = r.fitLines(left, columnWidth, measure)
+		b.right = r.fitLines(right, columnWidth, measure)
+		b.x = 10 + columnWidth + 20
+	} else {
+		b.left = r.fitLines(left, space, measure)
+	}
+	b.height = 5 + lineHeight*maxInt(len(b.left), len(b.right)) + 10
+	return b
+}
+
+// returns Options.HeaderImage resized to at most maxHeight, it is only
+// loaded once
+func (r *run) headerImage(maxHeight int) image.Image {
+	if r.opts.HeaderImage == "" || maxHeight < 1 {
+		return nil
+	}
+	if r.headerImg == nil && !r.headerImgFailed {
+		ov, err := imaging.Open(r.opts.HeaderImage)
+		if err != nil {
+			r.log.Error("error opening header overlay image")
+			r.headerImgFailed = true
+			return nil
+		}
+		r.headerImg = ov
+	}
+	if r.headerImg == nil {
+		return nil
+	}
+
+	if r.headerImg.Bounds().Dy() >= maxHeight {
+		return imaging.Resize(r.headerImg, 0, maxHeight, imaging.Lanczos)
+	}
+	return r.headerImg
+}
+
+// wraps or ellipsizes lines to width pixels as set by Options.HeaderOverflow
+func (r *run) fitLines(lines []string, width int, measure func(string) int) []string {
+	var fitted []string
+	for _, line := range lines {
+		if r.opts.HeaderOverflow == HeaderEllipsis {
+			fitted = append(fitted, ellipsize(line, width, measure))
+		} else {
+			fitted = append(fitted, wrapText(line, width, measure)...)
+		}
+	}
+	return fitted
+}
+
+// breaks s into lines of at most width pixels at spaces, words which are
+// longer than a line are broken anywhere
+func wrapText(s string, width int, measure func(string) int) []string {
+	if width < 1 || measure(s) <= width {
+		return []string{s}
+	}
+
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if measure(candidate) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		for measure(word) > width {
+			n := fitPrefix(word, width, measure)
+			lines = append(lines, word[:n])
+			word = word[n:]
+		}
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// cuts s off with "..." so it is at most width pixels wide
+func ellipsize(s string, width int, measure func(string) int) string {
+	const dots = "..."
+	if width < 1 || measure(s) <= width {
+		return s
+	}
+	n := fitPrefix(s, width-measure(dots), measure)
+	return strings.TrimRight(s[:n], " ") + dots
+}
+
+// returns the length in bytes of the longest prefix of s which is at most
+// width pixels wide, the prefix contains at least one rune
+func fitPrefix(s string, width int, measure func(string) int) int {
+	n := 0
+	for i, c := range s {
+		end := i + utf8.RuneLen(c)
+		if n > 0 && measure(s[:end]) > width {
+			break
+		}
+		n = end
+	}
+	return n
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,174 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestCreateHeader(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "Movie.MKV")
+	if err := ioutil.WriteFile(fn, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info := MediaInfo{Duration: 600000, Width: 640, Height: 360, FPS: 25, Bitrate: 800, VideoCodec: "h264", AudioCodec: "aac"}
+
+	tests := []struct {
+		lines []string
+		meta  bool
+		want  []string
+	}{
+		{nil, false, []string{"File Name: Movie.MKV", "File Size: 3 B", "Duration: 00:10:00", "Resolution: 640x360", "a comment"}},
+		{nil, true, []string{"File Name: Movie.MKV", "File Size: 3 B", "Duration: 00:10:00", "Resolution: 640x360", "FPS: 25.00, Bitrate: 800Kbp/s", "Codec: h264 / aac", "a comment"}},
+		{[]string{"{{.Container}} {{.SizeBytes}}", "{{if .Cropped}}cropped{{end}}", "{{.Hash}}"}, true, []string{"mkv 3", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"}},
+		{[]string{"{{.Name}}\n{{.VideoCodec}}"}, false, []string{"Movie.MKV", "h264"}},
+	}
+
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.HeaderLines, opts.HeaderMeta, opts.Comment = tt.lines, tt.meta, "a comment"
+		tmpls, _, err := parseHeader(opts)
+		if err != nil {
+			t.Fatalf("parseHeader(%q) got %v, wanted nil", tt.lines, err)
+		}
+		r := &run{opts: opts, input: fn, info: info, headerTmpls: tmpls, log: log.NewEntry(log.StandardLogger())}
+		if got := r.createHeader(tmpls); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("createHeader(%q) got %q want %q", tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestHeaderColumns(t *testing.T) {
+	opts := DefaultOptions()
+	opts.HeaderColumns, opts.HeaderMeta, opts.Comment = 2, true, "a comment"
+	left, right, err := parseHeader(opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+	r := &run{opts: opts, input: "movie.mkv", info: MediaInfo{Width: 640, Height: 360, FPS: 25}, log: log.NewEntry(log.StandardLogger())}
+
+	wantLeft := []string{"File Name: movie.mkv", "File Size: unknown", "a comment"}
+	if got := r.createHeader(left); !reflect.DeepEqual(got, wantLeft) {
+		t.Errorf("left column got %q want %q", got, wantLeft)
+	}
+	wantRight := []string{"Duration: 00:00:00", "Resolution: 640x360", "FPS: 25.00, Bitrate: 0Kbp/s", "Codec:  / "}
+	if got := r.createHeader(right); !reflect.DeepEqual(got, wantRight) {
+		t.Errorf("right column got %q want %q", got, wantRight)
+	}
+}
+
+// measures text as 10 pixels per byte
+func measureBytes(s string) int {
+	return 10 * len(s)
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		input string
+		width int
+		want  []string
+	}{
+		{"short", 100, []string{"short"}},
+		{"File Name: a long name", 100, []string{"File Name:", "a long", "name"}},
+		{"File: averyveryverylongname.mkv", 100, []string{"File:", "averyveryv", "erylongnam", "e.mkv"}},
+		{"anything", 0, []string{"anything"}},
+	}
+
+	for _, tt := range tests {
+		if got := wrapText(tt.input, tt.width, measureBytes); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wrapText(%q, %d) got %q want %q", tt.input, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestEllipsize(t *testing.T) {
+	tests := []struct {
+		input string
+		width int
+		want  string
+	}{
+		{"short", 100, "short"},
+		{"File Name: a long name", 100, "File Na..."},
+		{"File Name: a long name", 140, "File Name:..."},
+		{"äöü long", 50, "ä..."},
+	}
+
+	for _, tt := range tests {
+		if got := ellipsize(tt.input, tt.width, measureBytes); got != tt.want {
+			t.Errorf("ellipsize(%q, %d) got %q want %q", tt.input, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateHeaderWrap(t *testing.T) {
+	heights := map[string]int{}
+	for _, overflow := range []string{HeaderWrap, HeaderEllipsis} {
+		opts := syntheticOptions()
+		opts.Width = 100
+		opts.HeaderOverflow = overflow
+		opts.Comment = "a comment which is much too long for a contact sheet only two small thumbnails wide"
+		res, err := Generate(context.Background(), "synthetic.mkv", opts)
+		if err != nil {
+			t.Fatalf("got %v, wanted nil", err)
+		}
+		heights[overflow] = res.Sheet.Bounds().Dy()
+	}
+	if heights[HeaderWrap] <= heights[HeaderEllipsis] {
+		t.Errorf("wrapped sheet height got %d, wanted more than %d", heights[HeaderWrap], heights[HeaderEllipsis])
+	}
+}
+
+func TestGenerateHeaderPosition(t *testing.T) {
+	for _, position := range []string{PositionTop, PositionBottom, PositionLeft, PositionRight} {
+		opts := syntheticOptions()
+		opts.HeaderPosition = position
+		res, err := Generate(context.Background(), "synthetic.mkv", opts)
+		if err != nil {
+			t.Fatalf("%s: got %v, wanted nil", position, err)
+		}
+
+		// two columns of 200px with 10px padding and a 200px sidebar
+		width := 430
+		if position == PositionLeft || position == PositionRight {
+			width += 200
+		}
+		if got := res.Sheet.Bounds().Dx(); got != width {
+			t.Errorf("%s: sheet width got %d want %d", position, got, width)
+		}
+
+		// the cues point to the thumbnails wherever the header is
+		for i, cue := range res.cues {
+			thumb := res.Thumbnails[i]
+			if cue.rect.Size() != thumb.Bounds().Size() {
+				t.Fatalf("%s: cue %d got %v, wanted the size of the thumbnail", position, i, cue.rect)
+			}
+			for _, p := range []image.Point{{0, 0}, {100, 50}, {199, 112}} {
+				if got, want := res.Sheet.At(cue.rect.Min.X+p.X, cue.rect.Min.Y+p.Y), thumb.At(p.X, p.Y); got != want {
+					t.Errorf("%s: cue %d pixel %v got %v want %v", position, i, p, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestHeaderDataUnknownFile(t *testing.T) {
+	r := &run{opts: DefaultOptions(), input: "does-not-exist.mkv", log: log.NewEntry(log.StandardLogger())}
+	data := r.headerData()
+	if data.Size != "unknown" || data.Hash() != "unknown" || !data.Modified.IsZero() {
+		t.Errorf("got size %q, hash %q, modified %v, wanted them unknown", data.Size, data.Hash(), data.Modified)
+	}
+}
+
+func TestGenerateInvalidHeader(t *testing.T) {
+	opts := syntheticOptions()
+	opts.HeaderLines = []string{"{{.Name"}
+	if _, err := Generate(context.Background(), "synthetic.mkv", opts); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("got %v, wanted ErrInvalidOption", err)
+	}
+}
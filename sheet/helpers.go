@@ -0,0 +1,102 @@
+package sheet
+
+import (
+	"image"
+
+	"github.com/disintegration/gift"
+	"github.com/disintegration/imaging"
+	"github.com/koyachi/go-nude"
+)
+
+// decides if an image should be skipped based on settings, returns the name
+// of the detector which rejected it or an empty string
+func (r *run) skipImage(img image.Image) string {
+
+	if r.opts.SkipBlurry {
+		if blur := blurPercent(img); blur >= r.opts.BlurThreshold {
+			r.log.Debugf("image is considered blurry (%d), dropping frame", blur)
+			return "blurry"
+		}
+	}
+
+	if r.opts.SkipBlank {
+		if blank := blankPercent(img); blank >= r.opts.BlankThreshold {
+			r.log.Debugf("image is %d percent black, dropping frame", blank)
+			return "blank"
+		}
+	}
+
+	if r.opts.SFW {
+		isNude, err := nude.IsImageNude(img)
+		if err != nil {
+			r.log.Error(err)
+		} else if isNude {
+			r.log.Debugf("image skipped because of nudity detection")
+			return "sfw"
+		}
+	}
+
+	if r.opts.DedupeThreshold > 0 {
+		if r.isDuplicate(img) {
+			return "duplicate"
+		}
+	}
+
+	return ""
+
+}
+
+// returns the percentage of pixels without any edges, used to decide if an
+// image is to blury
+func blurPercent(img image.Image) int {
+	blur := 0
+	g := gift.New(
+		gift.Convolution(
+			[]float32{
+				-1, -1, -1,
+				-1, 8, -1,
+				-1, -1, -1,
+			},
+			false, false, false, 0.0),
+	)
+	img = imaging.Grayscale(img)
+	dst := image.NewRGBA(g.Bounds(img.Bounds()))
+	g.Draw(dst, img)
+	pixels := 0
+	for x := 0; x < dst.Bounds().Dx(); x++ {
+		for y := 0; y < dst.Bounds().Dy(); y++ {
+			_, _, b, _ := dst.At(x, y).RGBA()
+
+			pixels = pixels + 1
+			// only count blue channel < 4
+			if int(b) < 2056 {
+				blur = blur + 1
+			}
+		}
+	}
+
+	return int((float32(blur) / float32(pixels)) * 100)
+}
+
+// returns the percentage of dark or white pixels, used to decide if an image
+// should be considered blank
+func blankPercent(img image.Image) int {
+	blankPixels, allPixels := countBlankPixels(img)
+	return blankPixels / (allPixels / 100)
+}
+
+// counts pixels which are white and/or black, returns them and the number of all pixels
+func countBlankPixels(img image.Image) (blankPixels, allPixels int) {
+	pix := imaging.Clone(img).Pix
+	for i := 0; i+3 < len(pix); i += 4 {
+		r, g, b := int(pix[i]), int(pix[i+1]), int(pix[i+2])
+		//use 55?
+		if r < 50 && g < 50 && b < 50 {
+			blankPixels = blankPixels + 1
+		} else if r > 200 && g > 200 && b > 200 {
+			blankPixels = blankPixels + 1
+		}
+		allPixels = allPixels + 1
+	}
+	return blankPixels, allPixels
+}
@@ -0,0 +1,22 @@
+package sheet
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestBlankPercent(t *testing.T) {
+	black := imaging.New(100, 100, color.Black)
+	if got := blankPercent(black); got != 100 {
+		t.Errorf("black image got %d want 100", got)
+	}
+
+	grey := imaging.New(100, 100, color.NRGBA{128, 128, 128, 255})
+	grey = imaging.Paste(grey, imaging.New(50, 100, color.Black), image.Pt(0, 0))
+	if got := blankPercent(grey); got != 50 {
+		t.Errorf("half grey image got %d want 50", got)
+	}
+}
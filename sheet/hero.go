@@ -0,0 +1,112 @@
+package sheet
+
+import (
+	"fmt"
+	"image"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// LayoutGrid places all thumbnails in a uniform grid.
+	LayoutGrid = "grid"
+	// LayoutHero renders one featured thumbnail across several grid cells
+	// with the other thumbnails around and below it.
+	LayoutHero = "hero"
+)
+
+const (
+	// HeroMiddle features the middle thumbnail.
+	HeroMiddle = "middle"
+	// HeroSharpest features the thumbnail with the most edges.
+	HeroSharpest = "sharpest"
+)
+
+// returns the number of grid cells the featured thumbnail spans in each
+// direction, 0 if the hero layout is disabled
+func (r *run) heroSpan() int {
+	if r.opts.Layout != LayoutHero || r.opts.SingleImages {
+		return 0
+	}
+	if r.opts.HeroSpan < 1 {
+		return 1
+	}
+	return r.opts.HeroSpan
+}
+
+// adds the timestamp of Options.HeroFrame to stamps if it is one and
+// remembers its index as the featured thumbnail
+func (r *run) addHeroStamp(stamps []int64) ([]int64, error) {
+	if r.heroSpan() == 0 {
+		return stamps, nil
+	}
+	switch r.opts.HeroFrame {
+	case "", HeroMiddle, HeroSharpest:
+		return stamps, nil
+	}
+
+	stamp, err := stringToMS(r.opts.HeroFrame)
+	if err != nil {
+		return nil, fmt.Errorf("hero frame: %w", err)
+	}
+	if stamp > r.info.Duration {
+		return nil, fmt.Errorf("%w: hero frame %s is outside of the video (00:00:00-%s)", ErrInvalidRange, r.opts.HeroFrame, formatTimestamp(r.info.Duration))
+	}
+
+	i := sort.Search(len(stamps), func(i int) bool { return stamps[i] > stamp })
+	stamps = append(stamps, 0)
+	copy(stamps[i+1:], stamps[i:])
+	stamps[i] = stamp
+	r.hero = i
+	return stamps, nil
+}
+
+// returns the index of the thumbnail to feature
+func (r *run) pickHero(res *Result) int {
+	if r.hero >= 0 {
+		return r.hero
+	}
+
+	if r.opts.HeroFrame == HeroSharpest {
+		best, bestBlur := 0, 101
+		for i, thumb := range res.Thumbnails {
+			if blur := blurPercent(thumb); blur < bestBlur {
+				best, bestBlur = i, blur
+			}
+		}
+		return best
+	}
+
+	// the same thumbnail the watermark is placed on
+	return (len(res.Thumbnails) - 1) / 2
+}
+
+// returns the size of the featured thumbnail, it covers span x span cells
+// including the padding between them
+func (r *run) heroSize() (int, int) {
+	span := r.heroSpan()
+	w, h := r.thumbSize()
+	return span*w + (span-1)*r.opts.Padding, span*h + (span-1)*r.opts.Padding
+}
+
+// captures the featured frame again and renders it in the large size
+func (r *run) renderHero(res *Result) error {
+	i := r.pickHero(res)
+	stamp := res.Timestamps[i]
+	img, err := r.src.Image(stamp)
+	if err != nil {
+		return fmt.Errorf("%w: can't generate screenshot: %v", ErrUnreadableMedia, err)
+	}
+
+	r.log.Infof("featuring screenshot %02d/%02d at %s", i+1, len(res.Thumbnails), r.displayTimestamp(stamp))
+	r.hero = i
+	res.Thumbnails[i] = r.processImage(img, i, len(res.Thumbnails), stamp, true)
+	return nil
+}
+
+// resizes img to fill the size of the featured thumbnail
+func (r *run) resizeHero(img image.Image) image.Image {
+	w, h := r.heroSize()
+	return imaging.Fill(img, w, h, imaging.Center, imaging.Lanczos)
+}
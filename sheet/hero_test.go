@@ -0,0 +1,54 @@
+package sheet
+
+import (
+	"context"
+	"image"
+	"testing"
+)
+
+func TestCellsHero(t *testing.T) {
+	r := &run{opts: Options{Layout: LayoutHero, HeroSpan: 2}, columns: 4, hero: 2}
+	got := r.cells(6, r.hero)
+	want := []image.Rectangle{
+		image.Rect(0, 0, 1, 1),
+		image.Rect(3, 0, 4, 1),
+		image.Rect(1, 0, 3, 2),
+		image.Rect(0, 1, 1, 2),
+		image.Rect(3, 1, 4, 2),
+		image.Rect(0, 2, 1, 3),
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("cell %d got %v want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGenerateHero(t *testing.T) {
+	opts := syntheticOptions()
+	opts.Numcaps = 5
+	opts.Header = false
+	opts.Layout = LayoutHero
+	opts.HeroFrame = "00:01:00"
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	// the featured frame is captured in addition to the other thumbnails
+	if len(res.Timestamps) != 6 || res.Timestamps[0] != 60000 {
+		t.Fatalf("got timestamps %v, wanted 60000 first", res.Timestamps)
+	}
+
+	// two columns covered by the featured frame
+	if got := res.Thumbnails[0].Bounds(); got.Dx() != 410 || got.Dy() != 236 {
+		t.Errorf("featured thumbnail got %v want 410x236", got)
+	}
+	if got := res.Sheet.Bounds().Dx(); got != 430 {
+		t.Errorf("sheet width got %d want 430", got)
+	}
+	// featured frame in two rows, five thumbnails in three more rows
+	if got := res.Sheet.Bounds().Dy(); got != 5*113+6*10 {
+		t.Errorf("sheet height got %d want %d", got, 5*113+6*10)
+	}
+}
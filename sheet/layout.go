@@ -0,0 +1,305 @@
+package sheet
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// OrderRows places thumbnails left to right, then top to bottom.
+	OrderRows = "rows"
+	// OrderColumns places thumbnails top to bottom, then left to right.
+	OrderColumns = "columns"
+	// OrderSerpentine places thumbnails left to right in the first row,
+	// right to left in the second row and so on.
+	OrderSerpentine = "serpentine"
+)
+
+// DefaultSheetAspect is the sheet aspect ratio the layout is solved for if
+// Options.Interval decides the number of thumbnails and neither
+// Options.SheetAspect nor Options.Rows is set.
+const DefaultSheetAspect = 16.0 / 9.0
+
+// aspectPresets are named sheet aspect ratios accepted by ParseAspect.
+var aspectPresets = map[string]float64{
+	"a4":           210.0 / 297.0,
+	"a4-portrait":  210.0 / 297.0,
+	"a4-landscape": 297.0 / 210.0,
+	"letter":       8.5 / 11.0,
+	"square":       1,
+}
+
+// ParseAspect parses an aspect ratio (width / height) given as "16:9", as a
+// number like "1.5" or as one of the presets "a4" (portrait),
+// "a4-landscape", "letter" and "square".
+func ParseAspect(s string) (float64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if aspect, ok := aspectPresets[s]; ok {
+		return aspect, nil
+	}
+
+	var aspect float64
+	var err error
+	if x := strings.Split(s, ":"); len(x) == 2 {
+		var w, h float64
+		w, err = strconv.ParseFloat(x[0], 64)
+		if err == nil {
+			h, err = strconv.ParseFloat(x[1], 64)
+		}
+		if h != 0 {
+			aspect = w / h
+		}
+	} else {
+		aspect, err = strconv.ParseFloat(s, 64)
+	}
+	if err != nil || aspect <= 0 || math.IsInf(aspect, 0) || math.IsNaN(aspect) {
+		return 0, fmt.Errorf("%w: unknown aspect ratio %q", ErrInvalidOption, s)
+	}
+	return aspect, nil
+}
+
+// returns the size of a thumbnail after resizing to Options.Width or
+// Options.Height, based on the (cropped) size of the video
+func (r *run) thumbSize() (int, int) {
+	w, h := r.info.Width, r.info.Height
+	if !r.crop.Empty() {
+		w, h = r.crop.Dx(), r.crop.Dy()
+	}
+	if w <= 0 || h <= 0 {
+		return w, h
+	}
+
+	// same rounding as imaging.Resize
+	switch {
+	case r.opts.Width > 0:
+		return r.opts.Width, int(math.Max(1, math.Floor(float64(r.opts.Width)*float64(h)/float64(w)+0.5)))
+	case r.opts.Height > 0:
+		return int(math.Max(1, math.Floor(float64(r.opts.Height)*float64(w)/float64(h)+0.5))), r.opts.Height
+	}
+	return w, h
+}
+
+// decides the number of columns of the sheet for n thumbnails: Options.Rows
+// wins over Options.SheetAspect which wins over Options.Columns
+func (r *run) layout(n int) {
+	thumbs := n
+
+	// a featured thumbnail takes up the cells of several thumbnails
+	span := r.heroSpan()
+	if span > 1 {
+		n += span*span - 1
+	}
+
+	aspect := r.opts.SheetAspect
+	if aspect <= 0 && r.opts.Interval > 0 {
+		aspect = DefaultSheetAspect
+	}
+
+	switch {
+	case r.opts.Rows > 0:
+		r.columns = (n + r.opts.Rows - 1) / r.opts.Rows
+	case aspect > 0:
+		w, h := r.thumbSize()
+		r.columns = solveColumns(n, w, h, r.opts.Padding, aspect)
+	}
+
+	if r.columns > n {
+		r.columns = n
+	}
+	if r.columns < span {
+		r.columns = span
+	}
+	if r.columns < 1 {
+		r.columns = 1
+	}
+	// filling column by column uses fewer columns if the rows fill them up
+	// early, e.g. 9 thumbnails in 4 columns need 3 rows which fill 3 columns
+	if limit := r.pageCells(); r.opts.Order == OrderColumns && (limit <= 0 || n <= limit) {
+		rows := (n + r.columns - 1) / r.columns
+		if columns := (n + rows - 1) / rows; columns >= span {
+			r.columns = columns
+		}
+	}
+	r.log.Debugf("layout: %d columns, %d rows", r.columns, (n+r.columns-1)/r.columns)
+
+	// every page has to fit the sheet size, the page count is final once
+	// the featured thumbnail is known
+	r.pageCount = len(r.pages(thumbs))
+	if limit := r.pageCells(); limit > 0 && limit < n {
+		n = limit
+	}
+	r.fitSheet(n)
+}
+
+// returns the position of n thumbnails on a page in grid cells, the
+// featured thumbnail at index hero (-1 for none) is placed centered in the
+// first row and covers several cells, the others fill the free cells in the
+// order of Options.Order
+func (r *run) cells(n, hero int) []image.Rectangle {
+	span := r.heroSpan()
+	heroCol := (r.columns - span) / 2
+	heroCell := func(p image.Point) bool {
+		return span > 0 && hero >= 0 && p.Y < span && p.X >= heroCol && p.X < heroCol+span
+	}
+
+	total := n
+	if span > 0 && hero >= 0 {
+		total += span*span - 1
+	}
+	rows := (total + r.columns - 1) / r.columns
+	if rows < span {
+		rows = span
+	}
+
+	cells := make([]image.Rectangle, n)
+	next := 0
+	for i := range cells {
+		if span > 0 && i == hero {
+			cells[i] = image.Rect(heroCol, 0, heroCol+span, span)
+			continue
+		}
+		for {
+			p := r.cellAt(next, rows)
+			next++
+			if !heroCell(p) {
+				cells[i] = image.Rect(p.X, p.Y, p.X+1, p.Y+1)
+				break
+			}
+		}
+	}
+	return cells
+}
+
+// returns the position of the k-th cell of a grid with the given rows in the
+// order of Options.Order
+func (r *run) cellAt(k, rows int) image.Point {
+	switch r.opts.Order {
+	case OrderColumns:
+		return image.Pt(k/rows, k%rows)
+	case OrderSerpentine:
+		p := image.Pt(k%r.columns, k/r.columns)
+		if p.Y%2 == 1 {
+			p.X = r.columns - 1 - p.X
+		}
+		return p
+	default:
+		return image.Pt(k%r.columns, k/r.columns)
+	}
+}
+
+// sets the thumbnail size so n thumbnails fit into Options.SheetWidth and
+// Options.SheetHeight (including header and padding)
+func (r *run) fitSheet(n int) {
+	if r.opts.SheetWidth <= 0 && r.opts.SheetHeight <= 0 {
+		return
+	}
+
+	columns := r.columns
+	rows := (n + columns - 1) / columns
+	w, h := r.thumbSize()
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	// the largest thumbnail with the aspect ratio of the video which fits
+	// both constraints
+	// a sidebar header is as wide as a thumbnail
+	widthColumns := columns
+	if r.sidebar() {
+		widthColumns++
+	}
+
+	scale := math.Inf(1)
+	if r.opts.SheetWidth > 0 {
+		space := r.opts.SheetWidth - (columns+1)*r.opts.Padding
+		scale = math.Min(scale, float64(space)/float64(widthColumns*w))
+	}
+	// the header is as wide as the sheet and gets taller if its lines
+	// have to be wrapped
+	heightSpace := func() int {
+		sheetWidth := r.opts.SheetWidth
+		if sheetWidth <= 0 {
+			tw, _ := r.thumbSize()
+			sheetWidth = columns*tw + (columns+1)*r.opts.Padding
+		}
+		return r.opts.SheetHeight - r.headerHeight(sheetWidth) - (rows+1)*r.opts.Padding
+	}
+	if r.opts.SheetHeight > 0 {
+		scale = math.Min(scale, float64(heightSpace())/float64(rows*h))
+	}
+
+	width := int(float64(w) * scale)
+	r.opts.Width, r.opts.Height = width, 0
+	// the resized height is rounded, make sure the rows still fit
+	for r.opts.SheetHeight > 0 && width > 1 {
+		if _, height := r.thumbSize(); height*rows <= heightSpace() {
+			break
+		}
+		width--
+		r.opts.Width = width
+	}
+	if width < 1 {
+		r.opts.Width = 1
+	}
+	r.log.Debugf("thumbnail width set to %d to fit a %dx%d sheet", width, r.opts.SheetWidth, r.opts.SheetHeight)
+}
+
+// returns the factor the thumbnails of a page with cells of w x h pixels have
+// to be shrunk by to fit Options.SheetWidth and Options.SheetHeight, fitSheet
+// plans with the size of unfiltered thumbnails but filters like strip or
+// fancy make them larger
+func (r *run) pageScale(w, h, columns, rows int) float64 {
+	if (r.opts.SheetWidth <= 0 && r.opts.SheetHeight <= 0) || w <= 0 || h <= 0 {
+		return 1
+	}
+
+	widthColumns := columns
+	if r.sidebar() {
+		widthColumns++
+	}
+
+	scale := 1.0
+	if r.opts.SheetWidth > 0 {
+		space := r.opts.SheetWidth - (columns+1)*r.opts.Padding
+		scale = math.Min(scale, float64(space)/float64(widthColumns*w))
+	}
+	if r.opts.SheetHeight > 0 {
+		// a narrower sheet may wrap the header into more lines, so check
+		// again with the shrunk width until it fits
+		for i := 0; i < 3; i++ {
+			sheetWidth := r.opts.SheetWidth
+			if sheetWidth <= 0 {
+				sheetWidth = columns*int(float64(w)*scale) + (columns+1)*r.opts.Padding
+			}
+			space := r.opts.SheetHeight - r.headerHeight(sheetWidth) - (rows+1)*r.opts.Padding
+			scale = math.Min(scale, float64(space)/float64(rows*h))
+		}
+	}
+	return scale
+}
+
+// returns the number of columns for n thumbnails of w x h pixels with the
+// given padding which results in a sheet (without header) closest to aspect,
+// empty cells in the last row are penalized
+func solveColumns(n, w, h, padding int, aspect float64) int {
+	if n < 1 || w <= 0 || h <= 0 {
+		return 1
+	}
+
+	best, bestCost := 1, math.Inf(1)
+	for columns := 1; columns <= n; columns++ {
+		rows := (n + columns - 1) / columns
+		sheetWidth := columns*w + (columns+1)*padding
+		sheetHeight := rows*h + (rows+1)*padding
+
+		empty := rows*columns - n
+		cost := math.Abs(math.Log(float64(sheetWidth)/float64(sheetHeight)/aspect)) + float64(empty)/float64(columns)
+		if cost < bestCost {
+			best, bestCost = columns, cost
+		}
+	}
+	return best
+}
@@ -0,0 +1,181 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestParseAspect(t *testing.T) {
+	aspectTests := []struct {
+		input string
+		want  float64
+	}{
+		{"16:9", 16.0 / 9.0},
+		{"1.5", 1.5},
+		{"A4", 210.0 / 297.0},
+		{"a4-landscape", 297.0 / 210.0},
+	}
+
+	for _, tt := range aspectTests {
+		got, err := ParseAspect(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAspect(%q) got %v, %v want %v", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "wide", "16:0", "-1", "16:x"} {
+		if _, err := ParseAspect(input); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("ParseAspect(%q) got %v, wanted ErrInvalidOption", input, err)
+		}
+	}
+}
+
+func TestSolveColumns(t *testing.T) {
+	solveTests := []struct {
+		n, w, h int
+		aspect  float64
+		want    int
+	}{
+		{16, 200, 113, 16.0 / 9.0, 4},
+		{16, 200, 113, 210.0 / 297.0, 2},
+		{12, 200, 113, 16.0 / 9.0, 4},
+		{12, 200, 400, 16.0 / 9.0, 6},
+		{1, 200, 113, 4, 1},
+	}
+
+	for _, tt := range solveTests {
+		if got := solveColumns(tt.n, tt.w, tt.h, 10, tt.aspect); got != tt.want {
+			t.Errorf("%d thumbnails of %dx%d at %.2f got %d columns want %d", tt.n, tt.w, tt.h, tt.aspect, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateRows(t *testing.T) {
+	opts := syntheticOptions()
+	opts.Numcaps = 6
+	opts.Rows = 1
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	// six columns of 200px with 10px padding
+	if got := res.Sheet.Bounds().Dx(); got != 1270 {
+		t.Errorf("sheet width got %d want 1270", got)
+	}
+}
+
+func TestGenerateSheetSize(t *testing.T) {
+	sizeTests := []struct {
+		width, height int
+		header        bool
+		filter        string
+	}{
+		{1920, 0, true, ""},
+		{1000, 0, false, ""},
+		{0, 1080, true, ""},
+		{1920, 1080, true, ""},
+		{1080, 1920, false, ""},
+		// these filters make the thumbnails larger than planned
+		{1920, 0, true, "strip"},
+		{1920, 0, true, "fancy"},
+		{1920, 1080, true, "strip"},
+		{0, 1080, true, "fancy"},
+	}
+
+	for _, tt := range sizeTests {
+		opts := syntheticOptions()
+		opts.Numcaps = 9
+		opts.Columns = 3
+		opts.SheetWidth = tt.width
+		opts.SheetHeight = tt.height
+		opts.Header = tt.header
+		opts.Filter = tt.filter
+		res, err := Generate(context.Background(), "synthetic.mkv", opts)
+		if err != nil {
+			t.Fatalf("got %v, wanted nil", err)
+		}
+
+		b := res.Sheet.Bounds()
+		if tt.width > 0 && b.Dx() != tt.width {
+			t.Errorf("%dx%d %s: sheet width got %d", tt.width, tt.height, tt.filter, b.Dx())
+		}
+		if tt.height > 0 && b.Dy() != tt.height {
+			t.Errorf("%dx%d %s: sheet height got %d", tt.width, tt.height, tt.filter, b.Dy())
+		}
+		if thumb := res.Thumbnails[0].Bounds().Dx(); tt.width > 0 && tt.height == 0 && tt.filter == "" && thumb != (tt.width-40)/3 {
+			t.Errorf("%dx%d: thumbnail width got %d want %d", tt.width, tt.height, thumb, (tt.width-40)/3)
+		}
+	}
+}
+
+func TestCellsOrder(t *testing.T) {
+	orderTests := []struct {
+		order string
+		want  []image.Point
+	}{
+		{OrderRows, []image.Point{{0, 0}, {1, 0}, {2, 0}, {0, 1}, {1, 1}}},
+		{OrderColumns, []image.Point{{0, 0}, {0, 1}, {1, 0}, {1, 1}, {2, 0}}},
+		{OrderSerpentine, []image.Point{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {1, 1}}},
+	}
+
+	for _, tt := range orderTests {
+		r := &run{opts: Options{Order: tt.order}, columns: 3, hero: -1}
+		for i, cell := range r.cells(5, -1) {
+			if cell.Min != tt.want[i] {
+				t.Errorf("%s: cell %d got %v want %v", tt.order, i, cell.Min, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestGenerateOrderColumns(t *testing.T) {
+	opts := syntheticOptions()
+	opts.Numcaps = 9
+	opts.Columns = 4
+	opts.Order = OrderColumns
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	// three rows fill three columns, no empty column is left on the right
+	if got := res.Sheet.Bounds().Dx(); got != 3*200+4*10 {
+		t.Errorf("sheet width got %d want %d", got, 3*200+4*10)
+	}
+	for i, cue := range res.cues {
+		if cue.rect.Max.X > res.Sheet.Bounds().Dx()-10 {
+			t.Errorf("cue %d at %v is outside of the sheet", i, cue.rect)
+		}
+	}
+
+	// pages are sized by the columns they use
+	opts.MaxPerPage = 8
+	res, err = Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+	if got := res.Pages[1].Bounds().Dx(); got != 200+2*10 {
+		t.Errorf("last page width got %d want %d", got, 200+2*10)
+	}
+}
+
+func TestGenerateOrderVTT(t *testing.T) {
+	opts := syntheticOptions()
+	opts.Header = false
+	opts.Padding = 0
+	opts.Order = OrderColumns
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	// the second thumbnail is below the first one
+	want := "00:02:30.000 --> 00:05:00.000\nsynthetic.jpg#xywh=0,113,200,113\n"
+	if vtt := res.VTT("synthetic.jpg"); !strings.Contains(vtt, want) {
+		t.Errorf("got %q, wanted %q", vtt, want)
+	}
+}
@@ -0,0 +1,44 @@
+package sheet
+
+// page is a range of thumbnails composed into one contact sheet image.
+type page struct {
+	first, last int
+}
+
+// returns the maximum number of grid cells per page following
+// Options.MaxPerPage and Options.MaxRows, 0 means no limit
+func (r *run) pageCells() int {
+	limit := r.opts.MaxPerPage
+	if r.opts.MaxRows > 0 && (limit <= 0 || r.opts.MaxRows*r.columns < limit) {
+		limit = r.opts.MaxRows * r.columns
+	}
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// splits n thumbnails into pages, the featured thumbnail of the hero layout
+// counts as all the cells it covers
+func (r *run) pages(n int) []page {
+	limit := r.pageCells()
+	if limit == 0 {
+		return []page{{0, n}}
+	}
+
+	span := r.heroSpan()
+	var pages []page
+	first, used := 0, 0
+	for i := 0; i < n; i++ {
+		size := 1
+		if span > 1 && i == r.hero {
+			size = span * span
+		}
+		if used > 0 && used+size > limit {
+			pages = append(pages, page{first, i})
+			first, used = i, 0
+		}
+		used += size
+	}
+	return append(pages, page{first, n})
+}
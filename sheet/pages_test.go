@@ -0,0 +1,88 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPages(t *testing.T) {
+	pageTests := []struct {
+		maxRows, maxPerPage int
+		hero                int
+		want                []page
+	}{
+		{0, 0, -1, []page{{0, 10}}},
+		{2, 0, -1, []page{{0, 4}, {4, 8}, {8, 10}}},
+		{0, 3, -1, []page{{0, 3}, {3, 6}, {6, 9}, {9, 10}}},
+		{2, 3, -1, []page{{0, 3}, {3, 6}, {6, 9}, {9, 10}}},
+		{3, 0, 1, []page{{0, 3}, {3, 9}, {9, 10}}},
+	}
+
+	for _, tt := range pageTests {
+		r := &run{columns: 2, hero: tt.hero, opts: Options{MaxRows: tt.maxRows, MaxPerPage: tt.maxPerPage}}
+		if tt.hero >= 0 {
+			r.opts.Layout = LayoutHero
+			r.opts.HeroSpan = 2
+		}
+		if got := r.pages(10); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("max rows %d, max per page %d got %v want %v", tt.maxRows, tt.maxPerPage, got, tt.want)
+		}
+	}
+}
+
+func TestGeneratePages(t *testing.T) {
+	opts := syntheticOptions()
+	opts.Numcaps = 9
+	opts.MaxRows = 2
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	if len(res.Pages) != 3 {
+		t.Fatalf("got %d pages, wanted 3", len(res.Pages))
+	}
+	if res.Sheet != res.Pages[0] {
+		t.Errorf("sheet is not the first page")
+	}
+	// the last page has one row less, but the same header
+	if res.Pages[0].Bounds().Dy()-res.Pages[2].Bounds().Dy() != 113+10 {
+		t.Errorf("page heights got %d and %d, wanted one row difference", res.Pages[0].Bounds().Dy(), res.Pages[2].Bounds().Dy())
+	}
+
+	vtt := res.VTT("sheet-01.jpg", "sheet-02.jpg", "sheet-03.jpg")
+	for page, want := range map[string]int{"sheet-01.jpg": 4, "sheet-02.jpg": 4, "sheet-03.jpg": 1} {
+		if got := strings.Count(vtt, page+"#xywh="); got != want {
+			t.Errorf("got %d cues on %s, wanted %d", got, page, want)
+		}
+	}
+}
+
+func TestGeneratePagesHero(t *testing.T) {
+	opts := syntheticOptions()
+	opts.Numcaps = 9
+	opts.Layout = LayoutHero
+	opts.HeroSpan = 2
+	opts.MaxPerPage = 4
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	// the page holding only the featured thumbnail is as wide as the others
+	for i, pg := range res.Pages {
+		if got := pg.Bounds().Dx(); got != 430 {
+			t.Errorf("page %d width got %d want 430", i+1, got)
+		}
+	}
+
+	for _, limit := range []struct{ maxRows, maxPerPage int }{{1, 0}, {0, 3}} {
+		opts.MaxRows, opts.MaxPerPage = limit.maxRows, limit.maxPerPage
+		if _, err := Generate(context.Background(), "synthetic.mkv", opts); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("max rows %d, max per page %d got %v, wanted ErrInvalidOption", limit.maxRows, limit.maxPerPage, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// SelectEven spaces captures evenly over the video.
+	SelectEven = "even"
+	// SelectScenes captures the frames after the strongest scene cuts.
+	SelectScenes = "scenes"
+)
+
+// returns numcaps timestamps between start and end, one for each equally
+// sized window, at the strongest scene cut inside that window
+func (r *run) sceneStamps(ctx context.Context, start, end int64, numcaps int) ([]int64, error) {
+	samplesPerCap := r.opts.SceneSamples
+	if samplesPerCap < 2 {
+		samplesPerCap = 2
+	}
+	samples := numcaps * samplesPerCap
+	step := (end - start) / int64(samples)
+	if step < 1000 {
+		step = 1000
+	}
+
+	// sample the video at a coarse rate and compute the distance of
+	// every sample to the previous one
+	var sampleStamps []int64
+	var diffs []float64
+	var prev *image.Gray
+	for stamp := start; stamp <= end; stamp += step {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		img, err := r.src.Image(stamp)
+		if err != nil {
+			return nil, fmt.Errorf("%w: can't sample frame: %v", ErrUnreadableMedia, err)
+		}
+
+		small := sceneThumbnail(img)
+		diff := 0.0
+		if prev != nil {
+			diff = frameDistance(prev, small)
+		}
+		prev = small
+
+		sampleStamps = append(sampleStamps, stamp)
+		diffs = append(diffs, diff)
+	}
+	r.log.Debugf("sampled %d frames every %s for scene detection", len(sampleStamps), formatTimestamp(step))
+
+	// pick the strongest cut inside every window so the captures are
+	// spread across the whole range
+	window := (end - start) / int64(numcaps)
+	stamps := make([]int64, numcaps)
+	for i := range stamps {
+		winStart := start + int64(i)*window
+		winEnd := winStart + window
+		stamps[i] = winStart + window/2
+		best := -1.0
+		for j, stamp := range sampleStamps {
+			if stamp < winStart || stamp >= winEnd || j == 0 {
+				continue
+			}
+			if diffs[j] > best {
+				best = diffs[j]
+				stamps[i] = stamp
+			}
+		}
+		r.log.Debugf("scene %02d/%02d at %s (distance %.3f)", i+1, numcaps, formatTimestamp(stamps[i]), best)
+	}
+
+	return stamps, nil
+}
+
+// returns a small greyscale version of img used to compare frames
+func sceneThumbnail(img image.Image) *image.Gray {
+	small := imaging.Resize(img, 32, 18, imaging.Box)
+	gray := image.NewGray(small.Bounds())
+	for y := 0; y < small.Bounds().Dy(); y++ {
+		for x := 0; x < small.Bounds().Dx(); x++ {
+			gray.Set(x, y, small.At(x, y))
+		}
+	}
+	return gray
+}
+
+// returns the mean absolute difference of a and b from 0 (equal) to 1
+func frameDistance(a, b *image.Gray) float64 {
+	sum := 0
+	for i := range a.Pix {
+		d := int(a.Pix[i]) - int(b.Pix[i])
+		if d < 0 {
+			d = -d
+		}
+		sum += d
+	}
+	return float64(sum) / float64(len(a.Pix)*255)
+}
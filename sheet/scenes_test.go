@@ -0,0 +1,30 @@
+package sheet
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSelectScenes(t *testing.T) {
+	opts := syntheticOptions()
+	opts.Select = SelectScenes
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	if len(res.Timestamps) != 4 {
+		t.Fatalf("got %d timestamps, wanted 4", len(res.Timestamps))
+	}
+
+	// the synthetic source cuts to a new scene every minute, every capture
+	// should be the first sampled frame of a scene inside its quarter
+	for i, stamp := range res.Timestamps {
+		if stamp%60000 != 0 {
+			t.Errorf("timestamp %d at %d is not at a scene cut", i, stamp)
+		}
+		if stamp < int64(i)*150000 || stamp >= int64(i+1)*150000 {
+			t.Errorf("timestamp %d at %d is outside of its window", i, stamp)
+		}
+	}
+}
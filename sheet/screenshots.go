@@ -0,0 +1,397 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sort"
+	"sync"
+
+	"github.com/disintegration/imaging"
+)
+
+// generates screenshots and stores them together with their timestamps in res
+func (r *run) generateScreenshots(ctx context.Context, res *Result) error {
+	switch r.opts.SkipDirection {
+	case "", SkipForward, SkipBackward, SkipAlternate:
+	default:
+		return fmt.Errorf("%w: unknown skip direction %q", ErrInvalidOption, r.opts.SkipDirection)
+	}
+
+	switch r.opts.Layout {
+	case "", LayoutGrid, LayoutHero:
+	default:
+		return fmt.Errorf("%w: unknown layout %q", ErrInvalidOption, r.opts.Layout)
+	}
+
+	switch r.opts.Order {
+	case "", OrderRows, OrderColumns, OrderSerpentine:
+	default:
+		return fmt.Errorf("%w: unknown order %q", ErrInvalidOption, r.opts.Order)
+	}
+
+	// the featured thumbnail can't be split across pages
+	if span := r.heroSpan(); span > 1 && ((r.opts.MaxRows > 0 && r.opts.MaxRows < span) || (r.opts.MaxPerPage > 0 && r.opts.MaxPerPage < span*span)) {
+		return fmt.Errorf("%w: the featured thumbnail spans %d rows and %d cells, more than max rows %d or max per page %d allow", ErrInvalidOption, span, span*span, r.opts.MaxRows, r.opts.MaxPerPage)
+	}
+
+	if len(r.opts.At) > 0 {
+		stamps, err := r.explicitStamps()
+		if err == nil {
+			stamps, err = r.addHeroStamp(stamps)
+		}
+		if err != nil {
+			return err
+		}
+		r.layout(len(stamps))
+		return r.captureAll(ctx, res, r.slots(stamps, 0, r.info.Duration))
+	}
+
+	// truncate duration to full seconds
+	// this prevents empty/black images when the movie is some milliseconds longer
+	// ffmpeg then sometimes takes a black screenshot AFTER the movie finished for some reason
+	duration := 1000 * (r.info.Duration / 1000)
+	from, err := positionToMS(r.opts.From, duration)
+	if err != nil {
+		return fmt.Errorf("from: %w", err)
+	}
+	if from > duration {
+		return fmt.Errorf("%w: from %s is after the end of the video", ErrInvalidRange, r.opts.From)
+	}
+
+	// end stays 0 if to isn't set, a to which resolves to the start of the
+	// video (0%, -HH:MM:SS of the whole duration) leaves nothing to capture
+	var end int64
+	if positionSet(r.opts.To) {
+		end, err = positionToMS(r.opts.To, duration)
+		if err != nil {
+			return fmt.Errorf("to: %w", err)
+		}
+		if end <= 0 {
+			return fmt.Errorf("%w: to %s is at the start of the video", ErrInvalidRange, r.opts.To)
+		}
+	}
+	if from > end && end > 0 {
+		return fmt.Errorf("%w: from cant be higher than to", ErrInvalidRange)
+	}
+	if from > 0 {
+		r.log.Infof("First screenshot will be at %s (%s)", formatTimestamp(from), r.opts.From)
+	}
+	if end > 0 && from < end {
+		r.log.Infof("Last screenshot will be at %s (%s)", formatTimestamp(end), r.opts.To)
+	}
+
+	// intro and credits are only searched inside the captured range and
+	// ignored if they would leave nothing of it
+	if r.opts.SkipCredits && end == 0 {
+		credits, err := r.detectCredits(ctx, from, duration)
+		if err != nil {
+			return err
+		}
+		if credits <= from {
+			r.log.Infof("credits start before the first screenshot at %s, not skipping them", formatTimestamp(from))
+		} else {
+			duration = credits
+		}
+	}
+
+	if r.opts.SkipIntro && from == 0 {
+		limit := duration
+		if end > 0 {
+			limit = end
+		}
+		intro, err := r.detectIntro(ctx, duration, limit)
+		if err != nil {
+			return err
+		}
+		if intro >= limit {
+			r.log.Infof("intro lasts past the last screenshot at %s, not skipping it", formatTimestamp(limit))
+		} else {
+			from = intro
+		}
+	}
+
+	if end > 0 {
+		duration = end
+	}
+
+	if from > 0 {
+		duration = duration - from
+	}
+
+	numcaps := r.opts.Numcaps
+	if r.opts.Interval > 0 {
+		var durationSec = duration / 1000
+		var intervalSec = int64(r.opts.Interval)
+		if durationSec < intervalSec {
+			return fmt.Errorf("%w: use smaller interval or set numcaps instead", ErrIntervalTooLong)
+		}
+		numcaps = int(durationSec / intervalSec)
+		r.log.Debugf("interval option set, numcaps are set to %d", numcaps)
+	}
+
+	inc := duration / (int64(numcaps))
+
+	if end > 0 && from > 0 && numcaps > 1 {
+		inc = duration / (int64(numcaps) - 1)
+	}
+
+	if inc <= 60000 {
+		r.log.Warn("very small timestamps in use... consider decreasing numcaps")
+	}
+	if inc <= 9000 {
+		r.log.Errorf("interval (%ds) is way to small (less then 9s), please decrease numcaps", inc/1000)
+	}
+
+	d := inc
+	step := inc
+
+	if r.opts.Interval > 0 {
+		d = (int64(r.opts.Interval) * 1000)
+		step = d
+	}
+
+	if from > 0 {
+		d = from
+	}
+
+	stamps := make([]int64, numcaps)
+	switch r.opts.Select {
+	case "", SelectEven:
+		for i := range stamps {
+			stamps[i] = d
+			d += step
+		}
+	case SelectScenes:
+		var err error
+		stamps, err = r.sceneStamps(ctx, from, from+duration, numcaps)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown select mode %q", ErrInvalidOption, r.opts.Select)
+	}
+
+	stamps, err = r.addHeroStamp(stamps)
+	if err != nil {
+		return err
+	}
+
+	r.layout(len(stamps))
+	return r.captureAll(ctx, res, r.slots(stamps, from, from+duration))
+}
+
+// returns the timestamps of Options.At in ascending order, all of them have
+// to be inside the video
+func (r *run) explicitStamps() ([]int64, error) {
+	stamps := make([]int64, len(r.opts.At))
+	for i, at := range r.opts.At {
+		var err error
+		stamps[i], err = stringToMS(at)
+		if err != nil {
+			return nil, err
+		}
+		if stamps[i] < 0 || stamps[i] > r.info.Duration {
+			return nil, fmt.Errorf("%w: %s is outside of the video (00:00:00-%s)", ErrInvalidRange, at, formatTimestamp(r.info.Duration))
+		}
+	}
+	sort.Slice(stamps, func(i, j int) bool { return stamps[i] < stamps[j] })
+	r.log.Debugf("capturing %d explicit timestamps", len(stamps))
+	return stamps, nil
+}
+
+// captures and processes all slots using a pool of up to Options.Jobs
+// sources, thumbnails are stored in res in the order of slots
+func (r *run) captureAll(ctx context.Context, res *Result, slots []slot) error {
+	jobs := r.opts.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	if jobs > len(slots) {
+		jobs = len(slots)
+	}
+
+	// every source can only seek one position at a time,
+	// so each worker gets its own source for the same file
+	srcs := []FrameSource{r.src}
+	for len(srcs) < jobs {
+		src, err := r.opts.OpenSource(r.input)
+		if err != nil {
+			r.log.Warnf("unable to open additional source, using %d jobs: %v", len(srcs), err)
+			break
+		}
+		defer src.Close()
+		srcs = append(srcs, r.cropped(src))
+	}
+	r.log.Debugf("capturing %d screenshots with %d jobs", len(slots), len(srcs))
+
+	thumbnails := make([]image.Image, len(slots))
+	taken := make([]int64, len(slots))
+	if r.opts.DedupeThreshold > 0 {
+		r.acceptedDone = make([]chan struct{}, len(slots))
+		for i := range r.acceptedDone {
+			r.acceptedDone[i] = make(chan struct{})
+		}
+	}
+
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indexes := make(chan int)
+	errs := make(chan error, len(srcs))
+	var wg sync.WaitGroup
+	for _, src := range srcs {
+		wg.Add(1)
+		go func(src FrameSource) {
+			defer wg.Done()
+			for i := range indexes {
+				img, stamp, err := r.capture(workerCtx, src, i, slots[i])
+				if err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+
+				r.log.Infof("generating screenshot %02d/%02d at %s", i+1, len(slots), r.displayTimestamp(stamp))
+				thumbnails[i] = r.processImage(img, i, len(slots), stamp, false)
+				taken[i] = stamp
+			}
+		}(src)
+	}
+
+feed:
+	for i := range slots {
+		select {
+		case indexes <- i:
+		case <-workerCtx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	res.Thumbnails = append(res.Thumbnails, thumbnails...)
+	res.Timestamps = append(res.Timestamps, taken...)
+	return nil
+}
+
+// captures a single frame for slot number i using src, retrying inside the
+// window of s if the frame should be skipped based on settings or picking the
+// best of several candidates if enabled. Returns the frame and its timestamp.
+func (r *run) capture(ctx context.Context, src FrameSource, i int, s slot) (image.Image, int64, error) {
+	if r.opts.Candidates > 1 {
+		img, stamp, err := r.bestCandidate(ctx, src, i, s)
+		if err == nil && r.opts.DedupeThreshold > 0 {
+			r.acceptFrame(img, stamp, i)
+		}
+		return img, stamp, err
+	}
+
+	stamp := s.stamp
+	img, err := src.Image(stamp)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: can't generate screenshot: %v", ErrUnreadableMedia, err)
+	}
+
+	// should we skip any images?
+	if r.opts.SkipBlank || r.opts.SkipBlurry || r.opts.SFW || r.opts.DedupeThreshold > 0 {
+		if err := r.waitAccepted(ctx, i); err != nil {
+			return nil, 0, err
+		}
+		retries := r.retryStamps(s)
+		reason := r.skipImage(img)
+		for count := 0; reason != "" && count < len(retries); count++ {
+			r.log.Warnf("[%d/%d] frame skipped (%s) at: %s retry at: %s", count+1, len(retries), reason, formatTimestamp(stamp), formatTimestamp(retries[count]))
+			stamp = retries[count]
+			img, err = src.Image(stamp)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%w: can't generate screenshot: %v", ErrUnreadableMedia, err)
+			}
+			reason = r.skipImage(img)
+		}
+		if reason != "" {
+			r.log.Warnf("no frame left to retry for %s, using frame at %s which was rejected as %s", formatTimestamp(s.stamp), formatTimestamp(stamp), reason)
+		}
+	}
+
+	if r.opts.DedupeThreshold > 0 {
+		r.acceptFrame(img, stamp, i)
+	}
+
+	return img, stamp, nil
+}
+
+// resizes img and applies filters, timestamp and watermarks to it
+func (r *run) processImage(img image.Image, i, numcaps int, stamp int64, hero bool) image.Image {
+	disableTimestamps := r.disableTimestamps
+	caption := ""
+	if !disableTimestamps && !r.opts.SingleImages {
+		caption = r.captionText(i, numcaps, stamp)
+	}
+	if hero {
+		img = r.resizeHero(img)
+	} else if r.opts.Width > 0 {
+		img = imaging.Resize(img, r.opts.Width, 0, imaging.Lanczos)
+	} else if r.opts.Width == 0 && r.opts.Height > 0 {
+		img = imaging.Resize(img, 0, r.opts.Height, imaging.Lanczos)
+	}
+
+	//apply filters
+	for _, f := range r.filters {
+		if f.StampFirst && !disableTimestamps && !r.opts.SingleImages {
+			//draw timestamp to the image before filtering it!
+			img = r.overlayCaption(img, caption)
+			disableTimestamps = true
+		}
+		img = f.Apply(img, r.opts.BgContent)
+		r.log.Debugf("%s filter applied", f.Name)
+	}
+
+	if !disableTimestamps && !r.opts.SingleImages {
+		r.log.Debug("adding timestamp to image")
+		img = r.overlayCaption(img, caption)
+	}
+
+	//watermark middle image
+	if r.opts.Watermark != "" && (i == (numcaps-1)/2 || r.opts.SingleImages) {
+		ov, err := imaging.Open(r.opts.Watermark)
+		if err == nil {
+			if ov.Bounds().Dx() > img.Bounds().Dx() {
+				ov = imaging.Resize(ov, img.Bounds().Dx(), 0, imaging.Lanczos)
+			}
+			if ov.Bounds().Dy() > img.Bounds().Dy() {
+				ov = imaging.Resize(ov, 0, img.Bounds().Dy(), imaging.Lanczos)
+			}
+			posX := (img.Bounds().Dx() - ov.Bounds().Dx()) / 2
+			posY := (img.Bounds().Dy() - ov.Bounds().Dy()) / 2
+			img = imaging.Overlay(img, ov, image.Pt(posX, posY), 0.6)
+		}
+	}
+
+	if r.opts.WatermarkAll != "" {
+		ov, err := imaging.Open(r.opts.WatermarkAll)
+		if err == nil {
+			if ov.Bounds().Dx() > (img.Bounds().Dx() / 4) {
+				ov = imaging.Resize(ov, (img.Bounds().Dx() / 4), 0, imaging.Lanczos)
+			}
+			if ov.Bounds().Dy() > (img.Bounds().Dy() / 4) {
+				ov = imaging.Resize(ov, 0, (img.Bounds().Dy() / 4), imaging.Lanczos)
+			}
+			//default position for watermarking is bottom-left
+			posX := 10
+			posY := img.Bounds().Dy() - ov.Bounds().Dy() - 10
+			img = imaging.Overlay(img, ov, image.Pt(posX, posY), 0.6)
+		}
+	}
+
+	return img
+}
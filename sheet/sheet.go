@@ -0,0 +1,466 @@
+// Package sheet generates contact sheets (a grid of thumbnails with an
+// optional header) from video files. It holds no global state, so several
+// sheets can be generated concurrently in the same process.
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"path/filepath"
+	"sync"
+	"text/template"
+
+	"github.com/BurntSushi/freetype-go/freetype"
+	"github.com/BurntSushi/freetype-go/freetype/truetype"
+	"github.com/mutschler/mt/filter"
+	"github.com/mutschler/mt/internal/bindata"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultBlurThreshold is the default percentage of edge-less pixels
+	// above which a frame is considered blurry.
+	DefaultBlurThreshold = 62
+	// DefaultBlankThreshold is the default percentage of dark or white
+	// pixels above which a frame is considered blank.
+	DefaultBlankThreshold = 85
+)
+
+// Options controls how a contact sheet is generated. Use DefaultOptions to get
+// the same defaults the mt command line tool uses.
+type Options struct {
+	// Numcaps is the number of thumbnails to capture.
+	Numcaps int
+	// Columns is the number of columns in the contact sheet.
+	Columns int
+	// Rows is the number of rows in the contact sheet, the columns follow
+	// from Numcaps. 0 uses Columns or SheetAspect.
+	Rows int
+	// SheetAspect is the aspect ratio (width / height) the contact sheet
+	// should come close to, the columns and rows are chosen based on the
+	// thumbnail size. 0 uses Columns, see ParseAspect for common ratios.
+	SheetAspect float64
+	// SheetWidth is the exact width of the contact sheet, the thumbnail
+	// size follows from it, the columns and Padding. 0 uses Width.
+	SheetWidth int
+	// SheetHeight is the height of the contact sheet including the header,
+	// the thumbnails are shrunk to fit and centered. 0 disables it.
+	SheetHeight int
+	// Order is the order thumbnails are placed in: OrderRows, OrderColumns
+	// or OrderSerpentine.
+	Order string
+	// Layout is LayoutGrid or LayoutHero.
+	Layout string
+	// HeroFrame selects the featured thumbnail of LayoutHero: HeroMiddle,
+	// HeroSharpest or a timestamp which is captured in addition.
+	HeroFrame string
+	// HeroSpan is the number of grid cells the featured thumbnail spans in
+	// each direction.
+	HeroSpan int
+	// MaxRows is the maximum number of rows per page, more thumbnails are
+	// split into several pages. 0 disables it.
+	MaxRows int
+	// MaxPerPage is the maximum number of thumbnails per page, 0 disables it.
+	MaxPerPage int
+	// Padding is the padding (in pixels) around thumbnails.
+	Padding int
+	// Width is the width of a single thumbnail, 0 keeps the source width
+	// unless Height is set.
+	Width int
+	// Height is the height of a single thumbnail, only used if Width is 0.
+	Height int
+	// Font is the font name or path used for timestamps and the header.
+	Font string
+	// FontSize is the font size used for the header and for timestamps
+	// unless TimestampFontSize is set.
+	FontSize int
+	// DisableTimestamps disables drawing timestamps on thumbnails.
+	DisableTimestamps bool
+	// TimestampFormat is the format of the timestamps shown on thumbnails and
+	// the duration in the header, one of TimestampHMS, TimestampMillis or
+	// TimestampSMPTE.
+	TimestampFormat string
+	// Caption is a text/template for the text drawn on every thumbnail, see
+	// CaptionData for the available fields. Empty uses DefaultCaption.
+	Caption string
+	// TimestampOpacity is the opacity of timestamps, from 0.0 to 1.0.
+	TimestampOpacity float64
+	// TimestampPosition is where timestamps are drawn on a thumbnail, one of
+	// the Position constants like PositionBottomRight.
+	TimestampPosition string
+	// TimestampStyle is how timestamps are drawn: StyleBox, StyleShadow or
+	// StyleOutline.
+	TimestampStyle string
+	// TimestampMargin is the distance of timestamps to the edges of a
+	// thumbnail in pixels.
+	TimestampMargin int
+	// TimestampFontSize is the font size of timestamps, 0 uses FontSize.
+	TimestampFontSize int
+	// FgTimestamp is the font color of timestamps.
+	FgTimestamp color.RGBA
+	// BgTimestamp is the color of the box, shadow or outline of timestamps.
+	BgTimestamp color.RGBA
+	// SingleImages skips composing a sheet, only thumbnails are returned.
+	SingleImages bool
+	// BgHeader is the background color of the header.
+	BgHeader color.RGBA
+	// FgHeader is the font color of the header.
+	FgHeader color.RGBA
+	// BgContent is the background color of the content area.
+	BgContent color.RGBA
+	// HeaderImage is the path of an image drawn on the right of the header.
+	HeaderImage string
+	// Header enables the header above the thumbnails.
+	Header bool
+	// HeaderMeta adds HeaderMetaLines (codec, fps and bitrate) to the
+	// default header, it is ignored if HeaderLines is set.
+	HeaderMeta bool
+	// HeaderLines are text/template lines of the header, see HeaderData
+	// for the available fields. Lines which are empty after executing the
+	// template are left out. Empty uses DefaultHeaderLines.
+	HeaderLines []string
+	// HeaderPosition places the header above (PositionTop) or below
+	// (PositionBottom) the thumbnails or as a sidebar as wide as a thumbnail
+	// on their left (PositionLeft) or right (PositionRight).
+	HeaderPosition string
+	// HeaderColumns is the number of header columns, 1 or 2. The second
+	// column shows HeaderRightLines, see DefaultHeaderLines for the default.
+	HeaderColumns int
+	// HeaderRightLines are the text/template lines of the right header
+	// column if HeaderColumns is 2.
+	HeaderRightLines []string
+	// HeaderOverflow sets what happens to header lines which are too long:
+	// HeaderWrap or HeaderEllipsis.
+	HeaderOverflow string
+	// Comment is an additional line of text in the default header, it is
+	// available as {{.Comment}} in HeaderLines.
+	Comment string
+	// Watermark is the path of an image drawn on the middle thumbnail.
+	Watermark string
+	// WatermarkAll is the path of an image drawn on every thumbnail.
+	WatermarkAll string
+	// Filter is a comma separated list of filters applied to thumbnails, see
+	// filter.Parse for the syntax.
+	Filter string
+	// From is the first capture point, either in format HH:MM:SS, as a
+	// percentage of the duration (5%) or relative to the end (-00:03:00).
+	From string
+	// To is the last capture point in the same formats as From.
+	To string
+	// At lists the timestamps (HH:MM:SS[.ms]) to capture, overriding
+	// Numcaps, Interval, From, To and Select.
+	At []string
+	// Interval captures a thumbnail every Interval seconds, overriding Numcaps.
+	Interval int
+	// Select chooses how capture points are selected, SelectEven (default)
+	// or SelectScenes.
+	Select string
+	// SceneSamples is the number of frames sampled per capture to find
+	// scene cuts with SelectScenes.
+	SceneSamples int
+	// SkipCredits detects credits at the end of the video and ends the
+	// capture range before them, ignored if To is set.
+	SkipCredits bool
+	// SkipIntro detects an intro at the start of the video and starts the
+	// capture range after it, ignored if From is set.
+	SkipIntro bool
+	// CreditsBlankThreshold is the percentage of dark or white pixels and
+	// CreditsBlurThreshold the percentage of pixels without edges from which
+	// on a frame is considered part of intro or credits.
+	CreditsBlankThreshold int
+	CreditsBlurThreshold  int
+	// SkipBlank retries frames which are mostly dark or white.
+	SkipBlank bool
+	// SkipBlurry retries frames which are blurry.
+	SkipBlurry bool
+	// SFW retries frames which are detected as nude (EXPERIMENTAL).
+	SFW bool
+	// BlurThreshold is the threshold used for blur detection.
+	BlurThreshold int
+	// BlankThreshold is the threshold used for blank detection.
+	BlankThreshold int
+	// DedupeThreshold retries frames whose perceptual hash is within this
+	// hamming distance (0-64) of an already accepted thumbnail, 0 disables
+	// duplicate detection.
+	DedupeThreshold int
+	// Candidates is the number of candidate frames captured per thumbnail,
+	// the sharpest, best exposed one is kept. Values below 2 disable it.
+	Candidates int
+	// CandidateWindow is the time span in seconds around every capture
+	// point the candidates are spread over.
+	CandidateWindow int
+	// SkipRetries is the number of retries for a frame which should be
+	// skipped based on settings.
+	SkipRetries int
+	// SkipStep is the distance in seconds between retries.
+	SkipStep int
+	// SkipDirection is the direction retries are searched in, one of
+	// SkipForward, SkipBackward or SkipAlternate. Retries never leave the
+	// window between neighbouring capture points.
+	SkipDirection string
+	// AutoCrop detects constant black borders and crops them off every
+	// frame before it is resized.
+	AutoCrop bool
+	// Fast enables inaccurate but faster seeking.
+	Fast bool
+	// Jobs is the number of frames extracted and processed concurrently,
+	// every job opens its own FrameSource for the input.
+	Jobs int
+	// Log is used for all log output, defaults to the standard logger.
+	Log *log.Entry
+	// OpenSource opens the FrameSource for an input, it is called once per
+	// job. Defaults to OpenScreengen.
+	OpenSource func(input string) (FrameSource, error)
+}
+
+// DefaultOptions returns the default options of mt.
+func DefaultOptions() Options {
+	return Options{
+		Numcaps:               4,
+		Columns:               2,
+		Order:                 OrderRows,
+		Layout:                LayoutGrid,
+		HeroFrame:             HeroMiddle,
+		HeroSpan:              2,
+		Padding:               10,
+		Width:                 400,
+		Font:                  "DroidSans.ttf",
+		FontSize:              12,
+		TimestampOpacity:      1.0,
+		TimestampPosition:     PositionBottomRight,
+		TimestampStyle:        StyleBox,
+		TimestampMargin:       10,
+		FgTimestamp:           color.RGBA{255, 255, 255, 255},
+		BgTimestamp:           color.RGBA{0, 0, 0, 255},
+		BgHeader:              color.RGBA{0, 0, 0, 255},
+		FgHeader:              color.RGBA{255, 255, 255, 255},
+		BgContent:             color.RGBA{0, 0, 0, 255},
+		Header:                true,
+		HeaderPosition:        PositionTop,
+		HeaderColumns:         1,
+		HeaderOverflow:        HeaderWrap,
+		Comment:               "contact sheet created with mt (https://github.com/mutschler/mt)",
+		Filter:                "none",
+		From:                  "00:00:00",
+		To:                    "00:00:00",
+		BlurThreshold:         DefaultBlurThreshold,
+		BlankThreshold:        DefaultBlankThreshold,
+		Candidates:            1,
+		CandidateWindow:       10,
+		SkipRetries:           3,
+		SkipStep:              10,
+		SkipDirection:         SkipForward,
+		Select:                SelectEven,
+		SceneSamples:          10,
+		Jobs:                  1,
+		TimestampFormat:       TimestampHMS,
+		CreditsBlankThreshold: DefaultCreditsBlankThreshold,
+		CreditsBlurThreshold:  DefaultCreditsBlurThreshold,
+	}
+}
+
+// Result holds everything produced by Generate.
+type Result struct {
+	// Thumbnails are the captured (and filtered) thumbnails in sheet order.
+	Thumbnails []image.Image
+	// Timestamps are the capture points of Thumbnails in milliseconds.
+	Timestamps []int64
+	// Sheet is the composed contact sheet, nil if Options.SingleImages is set.
+	// If the sheet is split into several pages it is the first one.
+	Sheet image.Image
+	// Pages are all pages of the contact sheet, see Options.MaxRows and
+	// Options.MaxPerPage.
+	Pages []image.Image
+
+	// cues holds the page and position of every thumbnail.
+	cues []cue
+}
+
+// cue is the position of a thumbnail on a page of the contact sheet.
+type cue struct {
+	page int
+	rect image.Rectangle
+}
+
+// VTT returns the content of a WebVTT file which maps the capture times to
+// the thumbnails inside the sheet saved as imageNames, one name per page.
+func (r *Result) VTT(imageNames ...string) string {
+	vttContent := "WEBVTT\n"
+	start := formatTimestampMillis(0)
+	for idx, c := range r.cues {
+		var imgName string
+		if len(imageNames) > 0 {
+			page := c.page
+			if page >= len(imageNames) {
+				page = len(imageNames) - 1
+			}
+			_, imgName = filepath.Split(imageNames[page])
+		}
+		rect := c.rect
+		end := formatTimestampMillis(r.Timestamps[idx])
+		vttContent = fmt.Sprintf("%s\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n", vttContent, start, end, imgName, rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy())
+		start = end
+	}
+	return vttContent
+}
+
+// run holds the state of a single Generate call.
+type run struct {
+	opts  Options
+	input string
+	src   FrameSource
+	info  MediaInfo
+	font  *truetype.Font
+	log   *log.Entry
+
+	filters []filter.Instance
+	caption *template.Template
+	// the parsed Options.HeaderLines and Options.HeaderRightLines
+	headerTmpls      []*template.Template
+	headerRightTmpls []*template.Template
+	// hero is the index of the featured thumbnail of the hero layout, -1
+	// until it is known
+	hero int
+	// pageCount is the number of pages of the contact sheet
+	pageCount int
+	// header and headerRight hold the lines of the header columns once
+	// created
+	header        []string
+	headerRight   []string
+	headerCreated bool
+	// headerImg is Options.HeaderImage once loaded
+	headerImg       image.Image
+	headerImgFailed bool
+	// crop is the active picture every frame is cropped to, empty if
+	// autocrop is disabled or there are no borders
+	crop image.Rectangle
+
+	// accepted holds all thumbnails captured so far if duplicate
+	// detection is enabled, acceptedDone[i] is closed once the thumbnail
+	// of slot i is accepted
+	acceptedMu   sync.Mutex
+	accepted     []acceptedFrame
+	acceptedDone []chan struct{}
+
+	// columns and disableTimestamps start out as their Options counterpart
+	// but may be changed before capturing starts
+	columns           int
+	disableTimestamps bool
+}
+
+// Generate captures thumbnails from input (a file path or URL) and composes
+// them into a contact sheet according to opts. Errors wrap one of the Err
+// values of this package where applicable.
+func Generate(ctx context.Context, input string, opts Options) (*Result, error) {
+	r := &run{
+		opts:              opts,
+		input:             input,
+		columns:           opts.Columns,
+		hero:              -1,
+		disableTimestamps: opts.DisableTimestamps,
+		log:               opts.Log,
+	}
+	if r.log == nil {
+		r.log = log.NewEntry(log.StandardLogger())
+	}
+
+	filters, err := filter.Parse(opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+	r.filters = filters
+
+	if r.caption, err = parseCaption(opts.Caption); err != nil {
+		return nil, err
+	}
+	if r.headerTmpls, r.headerRightTmpls, err = parseHeader(opts); err != nil {
+		return nil, err
+	}
+
+	switch opts.HeaderPosition {
+	case "", PositionTop, PositionBottom, PositionLeft, PositionRight:
+	default:
+		return nil, fmt.Errorf("%w: unknown header position %q", ErrInvalidOption, opts.HeaderPosition)
+	}
+
+	switch opts.HeaderOverflow {
+	case "", HeaderWrap, HeaderEllipsis:
+	default:
+		return nil, fmt.Errorf("%w: unknown header overflow %q", ErrInvalidOption, opts.HeaderOverflow)
+	}
+	if opts.HeaderColumns < 0 || opts.HeaderColumns > 2 {
+		return nil, fmt.Errorf("%w: header columns must be 1 or 2, got %d", ErrInvalidOption, opts.HeaderColumns)
+	}
+
+	if opts.Numcaps <= 0 && opts.Interval <= 0 && len(opts.At) == 0 {
+		return nil, fmt.Errorf("%w: numcaps must be at least 1, got %d", ErrInvalidOption, opts.Numcaps)
+	}
+
+	switch opts.TimestampFormat {
+	case "", TimestampHMS, TimestampMillis, TimestampSMPTE:
+	default:
+		return nil, fmt.Errorf("%w: unknown timestamp format %q", ErrInvalidOption, opts.TimestampFormat)
+	}
+
+	switch opts.TimestampPosition {
+	case "", PositionTopLeft, PositionTop, PositionTopRight, PositionLeft, PositionCenter, PositionRight, PositionBottomLeft, PositionBottom, PositionBottomRight:
+	default:
+		return nil, fmt.Errorf("%w: unknown timestamp position %q", ErrInvalidOption, opts.TimestampPosition)
+	}
+
+	switch opts.TimestampStyle {
+	case "", StyleBox, StyleShadow, StyleOutline:
+	default:
+		return nil, fmt.Errorf("%w: unknown timestamp style %q", ErrInvalidOption, opts.TimestampStyle)
+	}
+
+	fontBytes, err := bindata.GetFont(opts.Font)
+	if err == nil {
+		r.font, err = freetype.ParseFont(fontBytes)
+	}
+	if err != nil {
+		r.log.Warnf("unable to load font, disabling timestamps and header: %v", err)
+		r.disableTimestamps = true
+		r.opts.Header = false
+	}
+
+	if r.opts.OpenSource == nil {
+		r.opts.OpenSource = func(input string) (FrameSource, error) {
+			return OpenScreengen(input, opts.Fast)
+		}
+	}
+
+	src, err := r.opts.OpenSource(input)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error reading video file: %v", ErrUnreadableMedia, err)
+	}
+	defer src.Close()
+	r.src = src
+	r.info = src.Info()
+
+	if opts.AutoCrop {
+		if r.crop, err = r.detectCrop(ctx); err != nil {
+			return nil, err
+		}
+		r.src = r.cropped(src)
+	}
+
+	res := &Result{}
+	if err := r.generateScreenshots(ctx, res); err != nil {
+		return nil, err
+	}
+
+	if r.heroSpan() > 0 && len(res.Thumbnails) > 0 {
+		if err := r.renderHero(res); err != nil {
+			return nil, err
+		}
+	}
+
+	if !opts.SingleImages && len(res.Thumbnails) > 0 {
+		r.makeContactSheet(res)
+	}
+
+	return res, nil
+}
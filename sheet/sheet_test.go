@@ -0,0 +1,201 @@
+package sheet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// returns options which capture from a ten minute synthetic video
+func syntheticOptions() Options {
+	opts := DefaultOptions()
+	opts.Width = 200
+	opts.OpenSource = func(input string) (FrameSource, error) {
+		return NewSyntheticSource(MediaInfo{
+			Duration:   600000,
+			Width:      640,
+			Height:     360,
+			FPS:        25,
+			VideoCodec: "synthetic",
+		}, 60000), nil
+	}
+	return opts
+}
+
+func TestGenerateUnreadableMedia(t *testing.T) {
+	opts := DefaultOptions()
+	opts.OpenSource = func(input string) (FrameSource, error) {
+		return nil, errors.New("no such file")
+	}
+	_, err := Generate(context.Background(), "does-not-exist.mkv", opts)
+	if !errors.Is(err, ErrUnreadableMedia) {
+		t.Errorf("got %v, wanted ErrUnreadableMedia", err)
+	}
+}
+
+func TestGenerateInvalidNumcaps(t *testing.T) {
+	for _, numcaps := range []int{0, -1} {
+		opts := syntheticOptions()
+		opts.Numcaps = numcaps
+		if _, err := Generate(context.Background(), "synthetic.mkv", opts); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("numcaps %d got %v, wanted ErrInvalidOption", numcaps, err)
+		}
+	}
+
+	// numcaps isn't used with an interval
+	opts := syntheticOptions()
+	opts.Numcaps, opts.Interval = 0, 120
+	if _, err := Generate(context.Background(), "synthetic.mkv", opts); err != nil {
+		t.Errorf("interval got %v, wanted nil", err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	res, err := Generate(context.Background(), "synthetic.mkv", syntheticOptions())
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	want := []int64{150000, 300000, 450000, 600000}
+	if len(res.Timestamps) != len(want) {
+		t.Fatalf("got %v timestamps, wanted %v", res.Timestamps, want)
+	}
+	for i := range want {
+		if res.Timestamps[i] != want[i] {
+			t.Errorf("timestamp %d got %d want %d", i, res.Timestamps[i], want[i])
+		}
+	}
+
+	if len(res.Thumbnails) != 4 {
+		t.Fatalf("got %d thumbnails, wanted 4", len(res.Thumbnails))
+	}
+	if got := res.Thumbnails[0].Bounds().Dx(); got != 200 {
+		t.Errorf("thumbnail width got %d want 200", got)
+	}
+
+	// two columns of 200px with 10px padding
+	if got := res.Sheet.Bounds().Dx(); got != 430 {
+		t.Errorf("sheet width got %d want 430", got)
+	}
+}
+
+func TestGenerateVTT(t *testing.T) {
+	opts := syntheticOptions()
+	opts.Header = false
+	opts.Padding = 0
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	vtt := res.VTT("/tmp/synthetic.jpg")
+	want := "WEBVTT\n\n00:00:00.000 --> 00:02:30.000\nsynthetic.jpg#xywh=0,0,200,113\n"
+	if !strings.HasPrefix(vtt, want) {
+		t.Errorf("got %q, wanted prefix %q", vtt, want)
+	}
+	if got := strings.Count(vtt, "#xywh="); got != 4 {
+		t.Errorf("got %d cues, wanted 4", got)
+	}
+}
+
+func TestGenerateAt(t *testing.T) {
+	opts := syntheticOptions()
+	opts.Header = false
+	opts.Padding = 0
+	opts.At = []string{"00:05:00", "00:01:30", "00:08:20.500"}
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	want := []int64{90000, 300000, 500500}
+	if len(res.Timestamps) != len(want) {
+		t.Fatalf("got %v timestamps, wanted %v", res.Timestamps, want)
+	}
+	for i := range want {
+		if res.Timestamps[i] != want[i] {
+			t.Errorf("timestamp %d got %d want %d", i, res.Timestamps[i], want[i])
+		}
+	}
+
+	vtt := res.VTT("synthetic.jpg")
+	if !strings.Contains(vtt, "00:01:30.000 --> 00:05:00.000\nsynthetic.jpg#xywh=200,0,200,113\n") {
+		t.Errorf("got %q, wanted a cue for the second timestamp", vtt)
+	}
+
+	opts.At = []string{"00:01:30", "00:11:00"}
+	if _, err := Generate(context.Background(), "synthetic.mkv", opts); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("got %v, wanted ErrInvalidRange", err)
+	}
+}
+
+func TestGenerateOutOfRange(t *testing.T) {
+	tests := []struct {
+		from, to string
+	}{
+		{"-00:20:00", "00:00:00"},
+		{"00:20:00", "00:00:00"},
+		{"00:00:00", "-00:20:00"},
+		{"00:00:00", "-00:10:00"},
+		{"00:00:00", "0%"},
+	}
+
+	for _, tt := range tests {
+		opts := syntheticOptions()
+		opts.From, opts.To = tt.from, tt.to
+		if _, err := Generate(context.Background(), "synthetic.mkv", opts); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("from %s to %s got %v, wanted ErrInvalidRange", tt.from, tt.to, err)
+		}
+	}
+}
+
+func TestGenerateJobs(t *testing.T) {
+	opts := syntheticOptions()
+	opts.Numcaps = 9
+	serial, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	opts.Jobs = 4
+	parallel, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	for i := range serial.Timestamps {
+		if serial.Timestamps[i] != parallel.Timestamps[i] {
+			t.Errorf("timestamp %d got %d want %d", i, parallel.Timestamps[i], serial.Timestamps[i])
+		}
+		if !bytes.Equal(imaging.Clone(serial.Thumbnails[i]).Pix, imaging.Clone(parallel.Thumbnails[i]).Pix) {
+			t.Errorf("thumbnail %d differs between serial and parallel capture", i)
+		}
+	}
+}
+
+func TestGenerateConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for _, columns := range []int{1, 2, 3, 4} {
+		wg.Add(1)
+		go func(columns int) {
+			defer wg.Done()
+			opts := syntheticOptions()
+			opts.Columns = columns
+			opts.Padding = 0
+			res, err := Generate(context.Background(), "synthetic.mkv", opts)
+			if err != nil {
+				t.Errorf("got %v, wanted nil", err)
+				return
+			}
+			if got := res.Sheet.Bounds().Dx(); got != 200*columns {
+				t.Errorf("sheet width got %d want %d", got, 200*columns)
+			}
+		}(columns)
+	}
+	wg.Wait()
+}
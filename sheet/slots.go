@@ -0,0 +1,111 @@
+package sheet
+
+const (
+	// SkipForward retries skipped frames after the capture point.
+	SkipForward = "forward"
+	// SkipBackward retries skipped frames before the capture point.
+	SkipBackward = "backward"
+	// SkipAlternate retries skipped frames alternating after and before the
+	// capture point.
+	SkipAlternate = "alternate"
+)
+
+// slot is a capture point together with the window (inclusive) a frame for
+// it may be taken from when skipping frames and the window halfway to the
+// neighbouring slots candidates are taken from. Windows of neighbouring slots
+// never overlap, so thumbnails keep their order.
+type slot struct {
+	stamp            int64
+	min, max         int64
+	nearMin, nearMax int64
+}
+
+// returns the slots of stamps (in ascending order) inside start and end,
+// the skip windows depend on Options.SkipDirection
+func (r *run) slots(stamps []int64, start, end int64) []slot {
+	slots := make([]slot, len(stamps))
+	for i, stamp := range stamps {
+		prev, next := start, end
+		if i > 0 {
+			prev = stamps[i-1]
+		}
+		if i < len(stamps)-1 {
+			next = stamps[i+1]
+		}
+
+		s := slot{stamp: stamp, min: stamp, max: stamp, nearMin: prev, nearMax: next}
+		if i > 0 {
+			s.nearMin = prev + (stamp-prev)/2
+		}
+		if i < len(stamps)-1 {
+			s.nearMax = stamp + (next-stamp)/2 - 1
+		}
+		if s.nearMin > stamp {
+			s.nearMin = stamp
+		}
+		if s.nearMax < stamp {
+			s.nearMax = stamp
+		}
+
+		switch r.opts.SkipDirection {
+		case SkipBackward:
+			s.min = prev
+			if i > 0 {
+				s.min = prev + 1
+			}
+		case SkipAlternate:
+			s.min, s.max = s.nearMin, s.nearMax
+		default:
+			s.max = next
+			if i < len(stamps)-1 {
+				s.max = next - 1
+			}
+		}
+		if s.min > stamp {
+			s.min = stamp
+		}
+		if s.max < stamp {
+			s.max = stamp
+		}
+		slots[i] = s
+	}
+	return slots
+}
+
+// returns up to Options.SkipRetries timestamps to retry s at, following
+// Options.SkipDirection and staying inside the window of s
+func (r *run) retryStamps(s slot) []int64 {
+	step := int64(r.opts.SkipStep) * 1000
+	if step <= 0 {
+		return nil
+	}
+
+	var stamps []int64
+	for k := int64(1); len(stamps) < r.opts.SkipRetries; k++ {
+		after, before := s.stamp+k*step, s.stamp-k*step
+		if after > s.max && before < s.min {
+			break
+		}
+
+		switch r.opts.SkipDirection {
+		case SkipBackward:
+			if before < s.min {
+				return stamps
+			}
+			stamps = append(stamps, before)
+		case SkipAlternate:
+			if after <= s.max {
+				stamps = append(stamps, after)
+			}
+			if before >= s.min && len(stamps) < r.opts.SkipRetries {
+				stamps = append(stamps, before)
+			}
+		default:
+			if after > s.max {
+				return stamps
+			}
+			stamps = append(stamps, after)
+		}
+	}
+	return stamps
+}
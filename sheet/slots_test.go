@@ -0,0 +1,94 @@
+package sheet
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestRetryStamps(t *testing.T) {
+	tests := []struct {
+		direction string
+		retries   int
+		want      []int64
+	}{
+		{SkipForward, 3, []int64{110000, 120000, 130000}},
+		{SkipForward, 10, []int64{110000, 120000, 130000, 140000}},
+		{SkipBackward, 3, []int64{90000, 80000, 70000}},
+		{SkipBackward, 10, []int64{90000, 80000, 70000, 60000}},
+		{SkipAlternate, 3, []int64{110000, 90000, 120000}},
+		{SkipAlternate, 10, []int64{110000, 90000, 120000, 80000, 130000, 70000, 140000, 60000}},
+	}
+
+	for _, test := range tests {
+		r := &run{opts: Options{SkipRetries: test.retries, SkipStep: 10, SkipDirection: test.direction}}
+		got := r.retryStamps(slot{stamp: 100000, min: 55000, max: 149999})
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s with %d retries got %v want %v", test.direction, test.retries, got, test.want)
+		}
+	}
+}
+
+func TestSlots(t *testing.T) {
+	stamps := []int64{100000, 200000, 300000}
+	tests := []struct {
+		direction string
+		want      []slot
+	}{
+		{SkipForward, []slot{{100000, 100000, 199999, 0, 149999}, {200000, 200000, 299999, 150000, 249999}, {300000, 300000, 400000, 250000, 400000}}},
+		{SkipBackward, []slot{{100000, 0, 100000, 0, 149999}, {200000, 100001, 200000, 150000, 249999}, {300000, 200001, 300000, 250000, 400000}}},
+		{SkipAlternate, []slot{{100000, 0, 149999, 0, 149999}, {200000, 150000, 249999, 150000, 249999}, {300000, 250000, 400000, 250000, 400000}}},
+	}
+
+	for _, test := range tests {
+		r := &run{opts: Options{SkipDirection: test.direction}}
+		if got := r.slots(stamps, 0, 400000); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s got %v want %v", test.direction, got, test.want)
+		}
+	}
+}
+
+func TestSkipBackward(t *testing.T) {
+	info := MediaInfo{Duration: 600000, Width: 640, Height: 360}
+	bars, _ := NewSyntheticSource(info, 0).Image(0)
+	black := imaging.New(640, 360, color.Black)
+
+	opts := syntheticOptions()
+	opts.SkipBlank = true
+	opts.SkipDirection = SkipBackward
+	opts.SkipStep = 5
+	opts.OpenSource = func(input string) (FrameSource, error) {
+		// only frames 10 seconds before a capture point are usable
+		return &funcSource{info: info, frame: func(ms int64) image.Image {
+			if ms%150000 == 140000 {
+				return bars
+			}
+			return black
+		}}, nil
+	}
+
+	res, err := Generate(context.Background(), "synthetic.mkv", opts)
+	if err != nil {
+		t.Fatalf("got %v, wanted nil", err)
+	}
+
+	for i, want := range []int64{140000, 290000, 440000, 590000} {
+		if res.Timestamps[i] != want {
+			t.Errorf("timestamp %d got %d want %d", i, res.Timestamps[i], want)
+		}
+	}
+}
+
+func TestSkipDirectionInvalid(t *testing.T) {
+	opts := syntheticOptions()
+	opts.SkipDirection = "sideways"
+
+	if _, err := Generate(context.Background(), "synthetic.mkv", opts); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("got %v, wanted %v", err, ErrInvalidOption)
+	}
+}
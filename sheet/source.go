@@ -0,0 +1,72 @@
+package sheet
+
+import (
+	"image"
+
+	"gitlab.com/opennota/screengen"
+)
+
+// MediaInfo describes the video behind a FrameSource.
+type MediaInfo struct {
+	// Duration is the duration of the video in milliseconds.
+	Duration int64
+	// Width is the width of the video in pixels.
+	Width int
+	// Height is the height of the video in pixels.
+	Height int
+	// FPS is the number of frames per second.
+	FPS float64
+	// Bitrate is the bitrate in kbit/s.
+	Bitrate int
+	// VideoCodec is the readable name of the video codec.
+	VideoCodec string
+	// AudioCodec is the readable name of the audio codec.
+	AudioCodec string
+}
+
+// FrameSource provides the frames of a video. A FrameSource is only used by
+// one goroutine at a time, concurrent captures open one source each.
+type FrameSource interface {
+	// Info returns the metadata of the video.
+	Info() MediaInfo
+	// Image returns the frame at ms milliseconds.
+	Image(ms int64) (image.Image, error)
+	// Close releases all resources of the source.
+	Close() error
+}
+
+// screengenSource is a FrameSource backed by ffmpeg through screengen.
+type screengenSource struct {
+	gen *screengen.Generator
+}
+
+// OpenScreengen opens input (a file path or URL) with ffmpeg, fast enables
+// inaccurate but faster seeking.
+func OpenScreengen(input string, fast bool) (FrameSource, error) {
+	gen, err := screengen.NewGenerator(input)
+	if err != nil {
+		return nil, err
+	}
+	gen.Fast = fast
+	return &screengenSource{gen: gen}, nil
+}
+
+func (s *screengenSource) Info() MediaInfo {
+	return MediaInfo{
+		Duration:   s.gen.Duration,
+		Width:      s.gen.Width(),
+		Height:     s.gen.Height(),
+		FPS:        s.gen.FPS,
+		Bitrate:    s.gen.Bitrate,
+		VideoCodec: s.gen.VideoCodecLongName,
+		AudioCodec: s.gen.AudioCodecLongName,
+	}
+}
+
+func (s *screengenSource) Image(ms int64) (image.Image, error) {
+	return s.gen.Image(ms)
+}
+
+func (s *screengenSource) Close() error {
+	return s.gen.Close()
+}
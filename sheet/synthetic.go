@@ -0,0 +1,96 @@
+package sheet
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/BurntSushi/freetype-go/freetype"
+	"github.com/BurntSushi/freetype-go/freetype/truetype"
+	"github.com/mutschler/mt/internal/bindata"
+)
+
+// testBars are the colors of the test pattern drawn by SyntheticSource
+var testBars = []color.RGBA{
+	{235, 235, 235, 255},
+	{235, 235, 16, 255},
+	{16, 235, 235, 255},
+	{16, 235, 16, 255},
+	{235, 16, 235, 255},
+	{235, 16, 16, 255},
+	{16, 16, 235, 255},
+	{16, 16, 16, 255},
+}
+
+// SyntheticSource is a FrameSource which draws color bar test patterns with
+// the timestamp burned in. It needs no real media, so the whole pipeline can
+// be run in tests.
+type SyntheticSource struct {
+	// MediaInfo is returned by Info.
+	MediaInfo
+	// SceneLength is the length of a scene in milliseconds, the color bars
+	// are rotated at every scene cut. 0 keeps the same pattern.
+	SceneLength int64
+
+	font *truetype.Font
+}
+
+// NewSyntheticSource returns a SyntheticSource for a video described by info.
+func NewSyntheticSource(info MediaInfo, sceneLength int64) *SyntheticSource {
+	s := &SyntheticSource{MediaInfo: info, SceneLength: sceneLength}
+	if fontBytes, err := bindata.Asset("DroidSans.ttf"); err == nil {
+		s.font, _ = freetype.ParseFont(fontBytes)
+	}
+	return s
+}
+
+// Info returns the metadata of the synthetic video.
+func (s *SyntheticSource) Info() MediaInfo {
+	return s.MediaInfo
+}
+
+// Image returns the test pattern frame at ms milliseconds.
+func (s *SyntheticSource) Image(ms int64) (image.Image, error) {
+	if ms < 0 || ms > s.Duration {
+		return nil, fmt.Errorf("timestamp %d out of range (0-%d)", ms, s.Duration)
+	}
+
+	scene := 0
+	if s.SceneLength > 0 {
+		scene = int(ms / s.SceneLength)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, s.Width, s.Height))
+	barWidth := s.Width/len(testBars) + 1
+	for i := range testBars {
+		bar := image.Rect(i*barWidth, 0, (i+1)*barWidth, s.Height)
+		c := testBars[(i+scene)%len(testBars)]
+		draw.Draw(img, bar, image.NewUniform(c), image.ZP, draw.Src)
+	}
+
+	if s.font != nil {
+		size := float64(s.Height / 10)
+		c := freetype.NewContext()
+		c.SetDPI(72)
+		c.SetFont(s.font)
+		c.SetFontSize(size)
+		c.SetClip(img.Bounds())
+		c.SetDst(img)
+		c.SetSrc(image.White)
+
+		text := formatTimestampMillis(ms)
+		w, h, _ := c.MeasureString(text)
+		box := image.Rect(0, 0, int(w)/256+20, int(h)/256+20)
+		box = box.Add(image.Pt((s.Width-box.Dx())/2, (s.Height-box.Dy())/2))
+		draw.Draw(img, box, image.Black, image.ZP, draw.Src)
+		c.DrawString(text, freetype.Pt(box.Min.X+10, box.Min.Y+10+int(c.PointToFix32(size)>>8)))
+	}
+
+	return img, nil
+}
+
+// Close does nothing, a SyntheticSource holds no resources.
+func (s *SyntheticSource) Close() error {
+	return nil
+}
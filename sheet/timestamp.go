@@ -0,0 +1,135 @@
+package sheet
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// TimestampHMS displays timestamps as HH:MM:SS, hours keep counting past 24.
+	TimestampHMS = "hms"
+	// TimestampMillis displays timestamps as HH:MM:SS.mmm.
+	TimestampMillis = "ms"
+	// TimestampSMPTE displays timestamps as SMPTE timecode HH:MM:SS:FF, the
+	// frame number is derived from the frame rate of the video.
+	TimestampSMPTE = "smpte"
+)
+
+// converts a position inside a video of the given duration to milliseconds,
+// s is either a timestamp accepted by stringToMS, a percentage of the
+// duration (5%) or a timestamp relative to the end (-00:03:00)
+func positionToMS(s string, duration int64) (int64, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasSuffix(s, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return 0, fmt.Errorf("%w: %q is not a percentage between 0%% and 100%%", ErrInvalidTimestamp, s)
+		}
+		return int64(float64(duration) * percent / 100), nil
+	}
+
+	if strings.HasPrefix(s, "-") {
+		ms, err := stringToMS(strings.TrimPrefix(s, "-"))
+		if err != nil {
+			return 0, err
+		}
+		if ms > duration {
+			return 0, fmt.Errorf("%w: %s is before the start of the video", ErrInvalidRange, s)
+		}
+		return duration - ms, nil
+	}
+
+	return stringToMS(s)
+}
+
+// reports whether s sets a position, an empty string and plain zero
+// timestamps like 00:00:00 leave it unset
+func positionSet(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return false
+	}
+	ms, err := stringToMS(s)
+	return err != nil || ms != 0
+}
+
+// converts a timestamp to milliseconds, accepted are HH:MM:SS[.ms],
+// MM:SS[.ms], durations like 90s or 1h2m3s and raw seconds like 90 or 90.5
+func stringToMS(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	// raw seconds
+	if sec, err := strconv.ParseFloat(s, 64); err == nil && sec >= 0 && !math.IsInf(sec, 1) {
+		return int64(math.Round(sec * 1000)), nil
+	}
+
+	// durations like 90s or 1h2m3s
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return d.Milliseconds(), nil
+	}
+
+	x := strings.Split(s, ":")
+	if len(x) != 2 && len(x) != 3 {
+		return 0, fmt.Errorf("%w: %q, use HH:MM:SS, MM:SS, 1h2m3s or seconds", ErrInvalidTimestamp, s)
+	}
+
+	// seconds may have a fraction, hours and minutes are whole numbers
+	sec, err := strconv.ParseFloat(x[len(x)-1], 64)
+	if err != nil || sec < 0 || sec >= 60 || strings.ContainsAny(x[len(x)-1], "eE+-") {
+		return 0, fmt.Errorf("%w: %q has invalid seconds", ErrInvalidTimestamp, s)
+	}
+	ms := int64(math.Round(sec * 1000))
+
+	factor := int64(60000)
+	for i := len(x) - 2; i >= 0; i-- {
+		n, err := strconv.ParseUint(x[i], 10, 63)
+		if err != nil || (i > 0 && n >= 60) {
+			return 0, fmt.Errorf("%w: %q has invalid hours or minutes", ErrInvalidTimestamp, s)
+		}
+		ms += int64(n) * factor
+		factor *= 60
+	}
+
+	return ms, nil
+}
+
+// formatTimestamp formats ms as HH:MM:SS, hours keep counting past 24
+func formatTimestamp(ms int64) string {
+	sec := ms / 1000
+	return fmt.Sprintf("%02d:%02d:%02d", sec/3600, sec/60%60, sec%60)
+}
+
+// formatTimestampMillis formats ms as HH:MM:SS.mmm
+func formatTimestampMillis(ms int64) string {
+	return fmt.Sprintf("%s.%03d", formatTimestamp(ms), ms%1000)
+}
+
+// formatTimecode formats ms as SMPTE timecode HH:MM:SS:FF at fps frames per
+// second, fractional rates like 29.97 are rounded up to full frames
+func formatTimecode(ms int64, fps float64) string {
+	frame := 0
+	if fps > 0 {
+		frame = int(float64(ms%1000) * math.Ceil(fps) / 1000)
+	}
+	return fmt.Sprintf("%s:%02d", formatTimestamp(ms), frame)
+}
+
+// formats ms for display on the contact sheet following
+// Options.TimestampFormat
+func (r *run) displayTimestamp(ms int64) string {
+	switch r.opts.TimestampFormat {
+	case TimestampMillis:
+		return formatTimestampMillis(ms)
+	case TimestampSMPTE:
+		return formatTimecode(ms, r.info.FPS)
+	default:
+		return formatTimestamp(ms)
+	}
+}
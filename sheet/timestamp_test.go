@@ -0,0 +1,112 @@
+package sheet
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStringToMS(t *testing.T) {
+	stringToMSTests := []struct {
+		input string
+		want  int64
+	}{
+		{"0", 0},
+		{"00:00:00", 0},
+		{"00:01:30", 90000},
+		{"01:00:00.500", 3600500},
+		{"30:00:00", 108000000},
+		{"01:30", 90000},
+		{"12:05.5", 725500},
+		{"90", 90000},
+		{"90.25", 90250},
+		{"90s", 90000},
+		{"1h2m3s", 3723000},
+		{"1500ms", 1500},
+	}
+
+	for _, tt := range stringToMSTests {
+		got, err := stringToMS(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("stringToMS(%q) got %v, %v want %v", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"abc", "1:2:3:4", "00:61:00", "00:00:75", "-5", "1x", "inf"} {
+		if _, err := stringToMS(input); !errors.Is(err, ErrInvalidTimestamp) {
+			t.Errorf("stringToMS(%q) got %v, wanted ErrInvalidTimestamp", input, err)
+		}
+	}
+}
+
+func TestPositionToMS(t *testing.T) {
+	positionToMSTests := []struct {
+		input string
+		want  int64
+	}{
+		{"00:01:30", 90000},
+		{"5%", 30000},
+		{"95%", 570000},
+		{"12.5%", 75000},
+		{"-00:03:00", 420000},
+		{"-00:00:00.500", 599500},
+		{"-3m", 420000},
+	}
+
+	for _, tt := range positionToMSTests {
+		got, err := positionToMS(tt.input, 600000)
+		if err != nil || got != tt.want {
+			t.Errorf("positionToMS(%q) got %v, %v want %v", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"abc%", "120%", "-abc"} {
+		if _, err := positionToMS(input, 600000); !errors.Is(err, ErrInvalidTimestamp) {
+			t.Errorf("positionToMS(%q) got %v, wanted ErrInvalidTimestamp", input, err)
+		}
+	}
+
+	if _, err := positionToMS("-00:20:00", 600000); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("positionToMS(%q) got %v, wanted ErrInvalidRange", "-00:20:00", err)
+	}
+}
+
+func TestPositionSet(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"", false},
+		{"00:00:00", false},
+		{"0", false},
+		{"0%", true},
+		{"-00:03:00", true},
+		{"00:01:30", true},
+	}
+
+	for _, tt := range tests {
+		if got := positionSet(tt.input); got != tt.want {
+			t.Errorf("positionSet(%q) got %t want %t", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	formatTests := []struct {
+		format string
+		ms     int64
+		want   string
+	}{
+		{TimestampHMS, 3723500, "01:02:03"},
+		{TimestampHMS, 108000000, "30:00:00"},
+		{TimestampMillis, 3723500, "01:02:03.500"},
+		{TimestampSMPTE, 3723500, "01:02:03:12"},
+		{TimestampSMPTE, 108000040, "30:00:00:01"},
+	}
+
+	for _, tt := range formatTests {
+		r := &run{opts: Options{TimestampFormat: tt.format}, info: MediaInfo{FPS: 25}}
+		if got := r.displayTimestamp(tt.ms); got != tt.want {
+			t.Errorf("%s of %d got %q want %q", tt.format, tt.ms, got, tt.want)
+		}
+	}
+}